@@ -0,0 +1,68 @@
+// Copyright 2016 Michal Witkowski. All Rights Reserved.
+// See LICENSE for licensing terms.
+
+package validator
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	gogoproto "github.com/gogo/protobuf/proto"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+)
+
+// anyMessage is implemented by the google.protobuf.Any types of both golang/protobuf and gogo/protobuf.
+type anyMessage interface {
+	GetTypeUrl() string
+	GetValue() []byte
+}
+
+// UnpackAny unmarshals the message held by a google.protobuf.Any and returns it with the full name of its type.
+// The type is looked up in the registry of google.golang.org/protobuf first, and then in the one of gogo/protobuf.
+// It returns an error if the type isn't registered or the message can't be unmarshaled.
+func UnpackAny(any anyMessage) (msg interface{}, typeName string, err error) {
+	typeURL := any.GetTypeUrl()
+	typeName = typeURL[strings.LastIndex(typeURL, "/")+1:]
+	msg, found, err := unmarshalMessage(typeName, any.GetValue())
+	if !found {
+		return nil, typeName, fmt.Errorf("unknown message type %q", typeURL)
+	}
+	return msg, typeName, err
+}
+
+// UnmarshalMessage unmarshals data as the message type with the given full name, e.g. "example.DeviceInfo", which is
+// looked up like the types of UnpackAny. It returns an error if the type isn't registered or data can't be unmarshaled.
+func UnmarshalMessage(typeName string, data []byte) (msg interface{}, err error) {
+	msg, found, err := unmarshalMessage(typeName, data)
+	if !found {
+		return nil, fmt.Errorf("unknown message type %q", typeName)
+	}
+	return msg, err
+}
+
+func unmarshalMessage(typeName string, data []byte) (msg interface{}, found bool, err error) {
+	if mt, err := protoregistry.GlobalTypes.FindMessageByName(protoreflect.FullName(typeName)); err == nil {
+		msg := mt.New().Interface()
+		if err := proto.Unmarshal(data, msg); err != nil {
+			return nil, true, err
+		}
+		return msg, true, nil
+	}
+	if t := gogoproto.MessageType(typeName); t != nil && t.Kind() == reflect.Ptr {
+		msg := reflect.New(t.Elem()).Interface().(gogoproto.Message)
+		if err := gogoproto.Unmarshal(data, msg); err != nil {
+			return nil, true, err
+		}
+		return msg, true, nil
+	}
+	return nil, false, nil
+}
+
+// ProtoAnyFieldError wraps the error of the message held by a google.protobuf.Any field, adding the type of the
+// message to the call stack in parentheses, e.g. "Details.(example.ErrorInfo).Reason".
+func ProtoAnyFieldError(typeName string, err error) error {
+	return ProtoFieldError("("+typeName+")", "("+typeName+")", err)
+}
@@ -0,0 +1,155 @@
+// Copyright 2016 Michal Witkowski. All Rights Reserved.
+// See LICENSE for licensing terms.
+
+/*
+Package cel evaluates the Common Expression Language constraints of the validator options.
+
+The expressions are type-checked by protoc-gen-govalidators against the descriptors of the messages, and the
+generated code compiles them once into programs that are evaluated by the Validate methods.
+*/
+package cel
+
+import (
+	"errors"
+	"fmt"
+
+	celgo "github.com/google/cel-go/cel"
+	"github.com/google/cel-go/common/types"
+	"google.golang.org/protobuf/protoadapt"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// Program is a compiled CEL constraint on messages, or on one of their fields.
+type Program struct {
+	// field is nil for constraints on the messages themselves.
+	field   protoreflect.FieldDescriptor
+	program celgo.Program
+	message string
+}
+
+// Compile compiles a CEL constraint on the messages described by md, or on their field with the given .proto name if
+// it's not empty. The value the constraint applies to is available to the expression as `this`. The message is the
+// error returned if the expression doesn't evaluate to true.
+func Compile(md protoreflect.MessageDescriptor, field string, expression string, message string) (*Program, error) {
+	p := &Program{message: message}
+	thisType := celgo.ObjectType(string(md.FullName()))
+	if field != "" {
+		p.field = md.Fields().ByName(protoreflect.Name(field))
+		if p.field == nil {
+			return nil, fmt.Errorf("message %v has no field %q", md.FullName(), field)
+		}
+		thisType = fieldType(p.field)
+	}
+	if p.message == "" && p.field != nil {
+		p.message = fmt.Sprintf("value must satisfy %q", expression)
+	} else if p.message == "" {
+		p.message = fmt.Sprintf("message must satisfy %q", expression)
+	}
+	env, err := celgo.NewEnv(celgo.TypeDescs(md.ParentFile()), celgo.Variable("this", thisType))
+	if err != nil {
+		return nil, err
+	}
+	ast, issues := env.Compile(expression)
+	if issues.Err() != nil {
+		return nil, issues.Err()
+	}
+	if !ast.OutputType().IsExactType(types.BoolType) {
+		return nil, fmt.Errorf("expression %q has type %v instead of bool", expression, ast.OutputType())
+	}
+	if p.program, err = env.Program(ast); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// MustCompile is like Compile, using the descriptor of msg. It panics if the constraint is invalid, which
+// protoc-gen-govalidators reports when generating the code.
+func MustCompile(msg protoadapt.MessageV1, field string, expression string, message string) *Program {
+	p, err := Compile(protoadapt.MessageV2Of(msg).ProtoReflect().Descriptor(), field, expression, message)
+	if err != nil {
+		panic(fmt.Sprintf("cel: compiling %q: %v", expression, err))
+	}
+	return p
+}
+
+// Eval evaluates the constraint on msg, or on its field. It returns an error holding the message of the constraint
+// if the expression doesn't evaluate to true, or the evaluation error if it fails.
+func (p *Program) Eval(msg protoadapt.MessageV1) error {
+	return p.EvalReflect(protoadapt.MessageV2Of(msg).ProtoReflect())
+}
+
+// EvalReflect is like Eval, for messages accessed using protobuf reflection.
+func (p *Program) EvalReflect(msg protoreflect.Message) error {
+	var this interface{} = msg.Interface()
+	if p.field != nil {
+		this = nativeValue(p.field, msg.Get(p.field))
+	}
+	out, _, err := p.program.Eval(map[string]interface{}{"this": this})
+	if err != nil {
+		return err
+	}
+	if out != types.True {
+		return errors.New(p.message)
+	}
+	return nil
+}
+
+// fieldType returns the CEL type of the values of a field.
+func fieldType(fd protoreflect.FieldDescriptor) *celgo.Type {
+	switch {
+	case fd.IsMap():
+		return celgo.MapType(kindType(fd.MapKey()), kindType(fd.MapValue()))
+	case fd.IsList():
+		return celgo.ListType(kindType(fd))
+	}
+	return kindType(fd)
+}
+
+func kindType(fd protoreflect.FieldDescriptor) *celgo.Type {
+	switch fd.Kind() {
+	case protoreflect.BoolKind:
+		return celgo.BoolType
+	case protoreflect.EnumKind, protoreflect.Int32Kind, protoreflect.Int64Kind, protoreflect.Sint32Kind,
+		protoreflect.Sint64Kind, protoreflect.Sfixed32Kind, protoreflect.Sfixed64Kind:
+		return celgo.IntType
+	case protoreflect.Uint32Kind, protoreflect.Uint64Kind, protoreflect.Fixed32Kind, protoreflect.Fixed64Kind:
+		return celgo.UintType
+	case protoreflect.FloatKind, protoreflect.DoubleKind:
+		return celgo.DoubleType
+	case protoreflect.StringKind:
+		return celgo.StringType
+	case protoreflect.BytesKind:
+		return celgo.BytesType
+	}
+	return celgo.ObjectType(string(fd.Message().FullName()))
+}
+
+// nativeValue converts the value of a field to the Go types understood by CEL.
+func nativeValue(fd protoreflect.FieldDescriptor, value protoreflect.Value) interface{} {
+	switch {
+	case fd.IsMap():
+		values := make(map[interface{}]interface{}, value.Map().Len())
+		value.Map().Range(func(key protoreflect.MapKey, value protoreflect.Value) bool {
+			values[key.Interface()] = nativeScalar(fd.MapValue(), value)
+			return true
+		})
+		return values
+	case fd.IsList():
+		values := make([]interface{}, value.List().Len())
+		for i := range values {
+			values[i] = nativeScalar(fd, value.List().Get(i))
+		}
+		return values
+	}
+	return nativeScalar(fd, value)
+}
+
+func nativeScalar(fd protoreflect.FieldDescriptor, value protoreflect.Value) interface{} {
+	switch fd.Kind() {
+	case protoreflect.EnumKind:
+		return int64(value.Enum())
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		return value.Message().Interface()
+	}
+	return value.Interface()
+}
@@ -0,0 +1,77 @@
+// Copyright 2016 Michal Witkowski. All Rights Reserved.
+// See LICENSE for licensing terms.
+
+package dynamic
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/mwitkow/go-proto-validators"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/protoadapt"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// anyValue checks the constraints of a google.protobuf.Any message.
+func (v *validation) anyValue(f field, msg protoreflect.Message, fv *validator.FieldValidator) {
+	if fv == nil || msg.Descriptor().FullName() != "google.protobuf.Any" {
+		return
+	}
+	any := anyMessage{msg}
+	typeURL := any.GetTypeUrl()
+	if len(fv.AnyIn) > 0 && !containsString(fv.AnyIn, typeURL) {
+		errorStr := fmt.Sprintf(`be one of the types %q`, fv.AnyIn)
+		v.errorString(f, "any_in", fv.AnyIn, typeURL, errorStr, fv)
+	}
+	if len(fv.AnyNotIn) > 0 && containsString(fv.AnyNotIn, typeURL) {
+		errorStr := fmt.Sprintf(`not be one of the types %q`, fv.AnyNotIn)
+		v.errorString(f, "any_not_in", fv.AnyNotIn, typeURL, errorStr, fv)
+	}
+	if !fv.GetAnyValidate() || v.done() {
+		return
+	}
+	unpacked, typeName, err := validator.UnpackAny(any)
+	if err != nil {
+		if fv.GetHumanError() == "" {
+			err = fmt.Errorf("message can't be unpacked: %v", err)
+		} else {
+			err = errors.New(fv.GetHumanError())
+		}
+		v.constraintError(f, "any_validate", true, typeURL, err)
+		return
+	}
+	var inner proto.Message
+	if m, ok := unpacked.(proto.Message); ok {
+		inner = m
+	} else {
+		inner = protoadapt.MessageV2Of(unpacked.(protoadapt.MessageV1))
+	}
+	nested := v.nested()
+	nested.message(inner.ProtoReflect())
+	if err := nested.err(); err != nil {
+		v.report(f.error(validator.ProtoAnyFieldError(typeName, err)))
+	}
+}
+
+// anyMessage adapts a google.protobuf.Any message to validator.UnpackAny.
+type anyMessage struct {
+	protoreflect.Message
+}
+
+func (m anyMessage) GetTypeUrl() string {
+	return m.Get(m.Descriptor().Fields().ByName("type_url")).String()
+}
+
+func (m anyMessage) GetValue() []byte {
+	return m.Get(m.Descriptor().Fields().ByName("value")).Bytes()
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
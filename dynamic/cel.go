@@ -0,0 +1,66 @@
+// Copyright 2016 Michal Witkowski. All Rights Reserved.
+// See LICENSE for licensing terms.
+
+package dynamic
+
+import (
+	"github.com/mwitkow/go-proto-validators"
+	"github.com/mwitkow/go-proto-validators/cel"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// celField checks the CEL expressions of a field, value being the value reported in violations.
+func (v *validation) celField(f field, msg protoreflect.Message, fd protoreflect.FieldDescriptor, value interface{}, fv *validator.FieldValidator) {
+	for _, expr := range fv.GetCel() {
+		if v.done() {
+			return
+		}
+		program, err := v.cache.compileCEL(msg.Descriptor(), string(fd.Name()), expr)
+		if err != nil {
+			v.report(f.error(err))
+			continue
+		}
+		if err := program.EvalReflect(msg); err != nil {
+			v.constraintError(f, "cel", expr.GetExpression(), value, err)
+		}
+	}
+}
+
+// celMessage checks the CEL expressions of the (validator.message) option of the message.
+func (v *validation) celMessage(msg protoreflect.Message, mv *validator.MessageValidator) {
+	for _, expr := range mv.GetCel() {
+		if v.done() {
+			return
+		}
+		program, err := v.cache.compileCEL(msg.Descriptor(), "", expr)
+		if err != nil {
+			v.report(err)
+			continue
+		}
+		if err := program.EvalReflect(msg); err != nil {
+			v.report(validator.ConstraintError("cel", expr.GetExpression(), msg.Interface(), err))
+		}
+	}
+}
+
+type celKey struct {
+	md                  protoreflect.MessageDescriptor
+	field               string
+	expression, message string
+}
+
+// compileCEL compiles a CEL expression of the message, or of one of its fields if field is set.
+func (c *Validator) compileCEL(md protoreflect.MessageDescriptor, field string, expr *validator.CelExpression) (*cel.Program, error) {
+	key := celKey{md: md, field: field, expression: expr.GetExpression(), message: expr.GetMessage()}
+	if program, ok := c.celPrograms.Load(key); ok {
+		return program.(*cel.Program), nil
+	}
+	program, err := cel.Compile(md, field, expr.GetExpression(), expr.GetMessage())
+	if err != nil {
+		return nil, err
+	}
+	if c.cacheable(md) {
+		c.celPrograms.Store(key, program)
+	}
+	return program, nil
+}
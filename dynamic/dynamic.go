@@ -0,0 +1,753 @@
+// Copyright 2016 Michal Witkowski. All Rights Reserved.
+// See LICENSE for licensing terms.
+
+/*
+Package dynamic validates messages using the (validator.field) and (validator.message) options of their descriptors,
+without generated code.
+
+The constraints are read from the options at runtime and checked using protobuf reflection, so any
+proto.Message can be validated, including dynamicpb messages built from descriptors loaded at runtime.
+The returned errors are the same as those returned by the generated Validate and ValidateAll methods,
+including the Violation details used by validator.BadRequestStatus.
+*/
+package dynamic
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	gogoproto "github.com/gogo/protobuf/proto"
+	descriptor "github.com/gogo/protobuf/protoc-gen-gogo/descriptor"
+	"github.com/gogo/protobuf/protoc-gen-gogo/generator"
+	"github.com/mwitkow/go-proto-validators"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+)
+
+// Validate checks msg against the constraints of its fields, returning the first violation like the generated
+// Validate method.
+func Validate(msg proto.Message) error {
+	return defaultValidator.Validate(msg)
+}
+
+// ValidateAll checks msg against the constraints of its fields, returning all violations like the generated
+// ValidateAll method.
+func ValidateAll(msg proto.Message) error {
+	return defaultValidator.ValidateAll(msg)
+}
+
+// A Validator validates messages like Validate and ValidateAll, and caches the options read from the descriptors of
+// the messages, and the regexes and CEL expressions compiled for them. Programs that load descriptors at runtime,
+// which are new descriptors every time they're loaded, should use a Validator per set of descriptors and drop it with
+// them. The zero value is ready to use.
+type Validator struct {
+	// registeredOnly restricts the caches to descriptors of the files in protoregistry.GlobalFiles.
+	registeredOnly bool
+
+	regexes           sync.Map // map[regexKey]*regexp.Regexp
+	celPrograms       sync.Map // map[celKey]*cel.Program
+	messageValidators sync.Map // map[protoreflect.MessageDescriptor]*validator.MessageValidator
+	oneofValidators   sync.Map // map[protoreflect.OneofDescriptor]*validator.OneofValidator
+	fieldValidators   sync.Map // map[protoreflect.FieldDescriptor]*validator.FieldValidator
+	fileValidators    sync.Map // map[protoreflect.FileDescriptor]*validator.FileValidator
+}
+
+// defaultValidator is used by Validate and ValidateAll. It only caches what it reads from the descriptors of
+// registered files, which are created once, so that validating messages of other descriptors doesn't grow it.
+var defaultValidator = &Validator{registeredOnly: true}
+
+// Validate checks msg like the Validate function.
+func (c *Validator) Validate(msg proto.Message) error {
+	v := &validation{cache: c}
+	v.message(msg.ProtoReflect())
+	return v.err()
+}
+
+// ValidateAll checks msg like the ValidateAll function.
+func (c *Validator) ValidateAll(msg proto.Message) error {
+	v := &validation{cache: c, all: true}
+	v.message(msg.ProtoReflect())
+	return v.err()
+}
+
+// cacheable returns whether what is read from the descriptor can be cached.
+func (c *Validator) cacheable(d protoreflect.Descriptor) bool {
+	if !c.registeredOnly {
+		return true
+	}
+	file := d.ParentFile()
+	if file == nil {
+		return false
+	}
+	registered, err := protoregistry.GlobalFiles.FindFileByPath(file.Path())
+	return err == nil && registered == file
+}
+
+type validation struct {
+	cache *Validator
+	all   bool
+	errs  validator.Errors
+}
+
+// nested returns a validation of a nested message, with the same cache and mode.
+func (v *validation) nested() *validation {
+	return &validation{cache: v.cache, all: v.all}
+}
+
+// done returns true if no further errors need to be reported.
+func (v *validation) done() bool {
+	return !v.all && len(v.errs) > 0
+}
+
+func (v *validation) report(err error) {
+	if !v.done() {
+		v.errs.Add(err)
+	}
+}
+
+func (v *validation) err() error {
+	if !v.all && len(v.errs) > 0 {
+		return v.errs[0]
+	}
+	return v.errs.ErrorOrNil()
+}
+
+// field identifies the field whose checks are being performed, mirroring the paths of the generated code.
+type field struct {
+	goName    string
+	protoName string
+	// mapKey is set while checking a map entry, and index while checking an element of a repeated field, so that errors
+	// include the entry key or the element index.
+	mapKey *protoreflect.MapKey
+	index  *int
+	// constraintPrefix qualifies the names of violated constraints, e.g. "map_key.".
+	constraintPrefix string
+}
+
+func (f field) error(err error) error {
+	switch {
+	case f.mapKey != nil:
+		return validator.ProtoMapFieldError(f.goName, f.protoName, f.mapKey.Interface(), err)
+	case f.index != nil:
+		return validator.ProtoIndexFieldError(f.goName, f.protoName, *f.index, err)
+	}
+	return validator.ProtoFieldError(f.goName, f.protoName, err)
+}
+
+func (v *validation) message(msg protoreflect.Message) {
+	fields := msg.Descriptor().Fields()
+	for i := 0; i < fields.Len() && !v.done(); i++ {
+		fd := fields.Get(i)
+		if od := fd.ContainingOneof(); od != nil && !od.IsSynthetic() && od.Fields().Get(0) == fd {
+			v.oneof(msg, od)
+		}
+		fv := v.cache.fieldValidator(fd)
+		if fv == nil && fd.Message() == nil {
+			continue
+		}
+		if fv == nil {
+			fv = &validator.FieldValidator{}
+		}
+		f := field{goName: goFieldName(fd), protoName: string(fd.Name())}
+		if fv.GetRequired() && checksPresence(fd) && !msg.Has(fd) {
+			v.constraintError(f, "required", true, nil, errors.New("value must be set"))
+		}
+		switch {
+		case fd.IsMap():
+			v.mapField(f, msg, fd, fv)
+		case fd.IsList():
+			list := msg.Get(fd).List()
+			v.repeatedCount(f, list, fv)
+			v.celField(f, msg, fd, listValue(list), fv)
+			for j := 0; j < list.Len() && !v.done(); j++ {
+				index, element := j, f
+				element.index = &index
+				v.value(element, fd, list.Get(j), fv)
+			}
+		case fd.Message() != nil:
+			if fv.GetMsgExists() && fd.ParentFile().Syntax() != protoreflect.Proto2 && !msg.Has(fd) {
+				v.constraintError(f, "msg_exists", true, nil, errors.New("message must exist"))
+			}
+			if msg.Has(fd) {
+				v.celField(f, msg, fd, goValue(msg.Get(fd)), fv)
+				v.value(f, fd, msg.Get(fd), fv)
+			}
+		default:
+			// Unset proto2 and oneof fields are not checked, proto3 scalars are checked even if they hold the zero value.
+			if !fd.HasPresence() || msg.Has(fd) {
+				v.celField(f, msg, fd, goValue(msg.Get(fd)), fv)
+				v.value(f, fd, msg.Get(fd), fv)
+			}
+		}
+	}
+	if !v.done() {
+		v.messageRules(msg)
+	}
+}
+
+// oneof checks the (validator.oneof) option of the oneof, before the constraints of its first field.
+func (v *validation) oneof(msg protoreflect.Message, od protoreflect.OneofDescriptor) {
+	if v.cache.oneofValidator(od).GetRequired() && msg.WhichOneof(od) == nil {
+		f := field{goName: generator.CamelCase(string(od.Name())), protoName: string(od.Name())}
+		v.constraintError(f, "required", true, nil, errors.New("oneof must be set"))
+	}
+}
+
+// checksPresence returns whether the required constraint applies to the field, like in the generated code.
+func checksPresence(fd protoreflect.FieldDescriptor) bool {
+	if fd.Cardinality() == protoreflect.Repeated {
+		return false
+	}
+	if oneOf := fd.ContainingOneof(); oneOf != nil && !oneOf.IsSynthetic() {
+		return false
+	}
+	if fd.ParentFile().Syntax() == protoreflect.Proto2 {
+		return true
+	}
+	// Use msg_exists for proto3 message fields.
+	return fd.HasOptionalKeyword() && fd.Message() == nil
+}
+
+func (v *validation) mapField(f field, msg protoreflect.Message, fd protoreflect.FieldDescriptor, fv *validator.FieldValidator) {
+	m := msg.Get(fd).Map()
+	if fv.MapCountMin != nil && int64(m.Len()) < fv.GetMapCountMin() {
+		errorStr := fmt.Sprint(`contain at least `, fv.GetMapCountMin(), ` entries`)
+		v.errorString(f, "map_count_min", fv.GetMapCountMin(), mapValue(m), errorStr, fv)
+	}
+	if fv.MapCountMax != nil && int64(m.Len()) > fv.GetMapCountMax() {
+		errorStr := fmt.Sprint(`contain at most `, fv.GetMapCountMax(), ` entries`)
+		v.errorString(f, "map_count_max", fv.GetMapCountMax(), mapValue(m), errorStr, fv)
+	}
+	v.celField(f, msg, fd, mapValue(m), fv)
+	keyValidator, valueValidator := fv.GetMapKey(), fv.GetMapValue()
+	if keyValidator == nil {
+		keyValidator = &validator.FieldValidator{}
+	}
+	if valueValidator == nil {
+		valueValidator = &validator.FieldValidator{}
+	}
+	// Like in the generated code, the entries are checked in the order of their keys.
+	keys := make([]protoreflect.MapKey, 0, m.Len())
+	m.Range(func(key protoreflect.MapKey, _ protoreflect.Value) bool {
+		keys = append(keys, key)
+		return true
+	})
+	sort.Slice(keys, func(i, j int) bool {
+		return validator.LessMapKey(keys[i].Interface(), keys[j].Interface())
+	})
+	for i := 0; i < len(keys) && !v.done(); i++ {
+		key := keys[i]
+		entry := f
+		entry.mapKey = &key
+		entry.constraintPrefix = "map_key."
+		v.value(entry, fd.MapKey(), key.Value(), keyValidator)
+		entry.constraintPrefix = "map_value."
+		v.value(entry, fd.MapValue(), m.Get(key), valueValidator)
+	}
+}
+
+// value checks a single value of the field, i.e. the field itself or an element of a repeated field.
+func (v *validation) value(f field, fd protoreflect.FieldDescriptor, value protoreflect.Value, fv *validator.FieldValidator) {
+	switch fd.Kind() {
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		v.timeValue(f, value.Message(), fv)
+		v.anyValue(f, value.Message(), fv)
+		if wrapperTypes[fd.Message().FullName()] && fv != nil {
+			// The scalar constraints apply to the value of wrapper messages.
+			valueField := fd.Message().Fields().ByName("value")
+			v.value(f, valueField, value.Message().Get(valueField), fv)
+		}
+		nested := v.nested()
+		nested.message(value.Message())
+		if err := nested.err(); err != nil {
+			v.report(f.error(err))
+		}
+	case protoreflect.StringKind:
+		v.string(f, fd, value.String(), fv)
+	case protoreflect.EnumKind:
+		v.enum(f, fd.Enum(), value.Enum(), fv)
+	case protoreflect.BytesKind:
+		v.length(f, value.Interface(), len(value.Bytes()), fv)
+	case protoreflect.Int32Kind, protoreflect.Int64Kind, protoreflect.Sint32Kind, protoreflect.Sint64Kind:
+		v.int(f, value, fv)
+	case protoreflect.Uint32Kind, protoreflect.Uint64Kind:
+		v.int(f, value, fv)
+	case protoreflect.Fixed32Kind, protoreflect.Fixed64Kind, protoreflect.Sfixed32Kind, protoreflect.Sfixed64Kind:
+		v.int(f, value, fv)
+	case protoreflect.FloatKind, protoreflect.DoubleKind:
+		v.float(f, value, fd.Kind() == protoreflect.FloatKind, fv)
+	}
+}
+
+// wrapperTypes are the google.protobuf wrapper messages, whose value field holds a scalar.
+var wrapperTypes = map[protoreflect.FullName]bool{
+	"google.protobuf.DoubleValue": true, "google.protobuf.FloatValue": true,
+	"google.protobuf.Int64Value": true, "google.protobuf.UInt64Value": true,
+	"google.protobuf.Int32Value": true, "google.protobuf.UInt32Value": true,
+	"google.protobuf.BoolValue": true, "google.protobuf.StringValue": true, "google.protobuf.BytesValue": true,
+}
+
+func (v *validation) int(f field, value protoreflect.Value, fv *validator.FieldValidator) {
+	if fv.IntGt != nil && !(compareInt(value, fv.GetIntGt()) > 0) {
+		errorStr := fmt.Sprintf(`be greater than '%d'`, fv.GetIntGt())
+		v.errorString(f, "int_gt", fv.GetIntGt(), value.Interface(), errorStr, fv)
+	}
+	if fv.IntLt != nil && !(compareInt(value, fv.GetIntLt()) < 0) {
+		errorStr := fmt.Sprintf(`be less than '%d'`, fv.GetIntLt())
+		v.errorString(f, "int_lt", fv.GetIntLt(), value.Interface(), errorStr, fv)
+	}
+	if fv.IntGte != nil && !(compareInt(value, fv.GetIntGte()) >= 0) {
+		errorStr := fmt.Sprintf(`be greater than or equal to '%d'`, fv.GetIntGte())
+		v.errorString(f, "int_gte", fv.GetIntGte(), value.Interface(), errorStr, fv)
+	}
+	if fv.IntLte != nil && !(compareInt(value, fv.GetIntLte()) <= 0) {
+		errorStr := fmt.Sprintf(`be less than or equal to '%d'`, fv.GetIntLte())
+		v.errorString(f, "int_lte", fv.GetIntLte(), value.Interface(), errorStr, fv)
+	}
+	if len(fv.IntIn) > 0 && !containsInt(fv.IntIn, value) {
+		errorStr := fmt.Sprintf(`be one of the values %v`, fv.IntIn)
+		v.errorString(f, "int_in", fv.IntIn, value.Interface(), errorStr, fv)
+	}
+	if len(fv.IntNotIn) > 0 && containsInt(fv.IntNotIn, value) {
+		errorStr := fmt.Sprintf(`not be one of the values %v`, fv.IntNotIn)
+		v.errorString(f, "int_not_in", fv.IntNotIn, value.Interface(), errorStr, fv)
+	}
+	if fv.IntConst != nil && compareInt(value, fv.GetIntConst()) != 0 {
+		errorStr := fmt.Sprintf(`be equal to '%d'`, fv.GetIntConst())
+		v.errorString(f, "int_const", fv.GetIntConst(), value.Interface(), errorStr, fv)
+	}
+	if fv.UintGt != nil && !(compareUint(value, fv.GetUintGt()) > 0) {
+		errorStr := fmt.Sprintf(`be greater than '%d'`, fv.GetUintGt())
+		v.errorString(f, "uint_gt", fv.GetUintGt(), value.Interface(), errorStr, fv)
+	}
+	if fv.UintLt != nil && !(compareUint(value, fv.GetUintLt()) < 0) {
+		errorStr := fmt.Sprintf(`be less than '%d'`, fv.GetUintLt())
+		v.errorString(f, "uint_lt", fv.GetUintLt(), value.Interface(), errorStr, fv)
+	}
+	if fv.UintGte != nil && !(compareUint(value, fv.GetUintGte()) >= 0) {
+		errorStr := fmt.Sprintf(`be greater than or equal to '%d'`, fv.GetUintGte())
+		v.errorString(f, "uint_gte", fv.GetUintGte(), value.Interface(), errorStr, fv)
+	}
+	if fv.UintLte != nil && !(compareUint(value, fv.GetUintLte()) <= 0) {
+		errorStr := fmt.Sprintf(`be less than or equal to '%d'`, fv.GetUintLte())
+		v.errorString(f, "uint_lte", fv.GetUintLte(), value.Interface(), errorStr, fv)
+	}
+}
+
+// containsInt returns whether the integer value is equal to one of values.
+func containsInt(values []int64, value protoreflect.Value) bool {
+	for _, v := range values {
+		if compareInt(value, v) == 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// compareInt returns -1, 0 or 1 depending on whether the integer value is less than, equal to or greater than bound.
+func compareInt(value protoreflect.Value, bound int64) int {
+	switch value.Interface().(type) {
+	case uint32, uint64:
+		if bound < 0 || value.Uint() > uint64(bound) {
+			return 1
+		} else if value.Uint() < uint64(bound) {
+			return -1
+		}
+		return 0
+	}
+	if value.Int() > bound {
+		return 1
+	} else if value.Int() < bound {
+		return -1
+	}
+	return 0
+}
+
+// compareUint returns -1, 0 or 1 depending on whether the integer value is less than, equal to or greater than bound.
+// The plugin only allows uint constraints on unsigned fields, but descriptors built at runtime can have them anywhere.
+func compareUint(value protoreflect.Value, bound uint64) int {
+	var x uint64
+	switch value.Interface().(type) {
+	case uint32, uint64:
+		x = value.Uint()
+	default:
+		if value.Int() < 0 {
+			return -1
+		}
+		x = uint64(value.Int())
+	}
+	if x > bound {
+		return 1
+	} else if x < bound {
+		return -1
+	}
+	return 0
+}
+
+func (v *validation) float(f field, value protoreflect.Value, isFloat32 bool, fv *validator.FieldValidator) {
+	// Float fields are compared using float32 arithmetic, like in the generated code.
+	round := func(x float64) float64 { return x }
+	if isFloat32 {
+		round = func(x float64) float64 { return float64(float32(x)) }
+	}
+	x := floatValue(value)
+	epsilon := round(fv.GetFloatEpsilon())
+	if fv.GetFloatNotNan() && math.IsNaN(x) {
+		v.errorString(f, "float_not_nan", true, value.Interface(), "be a number", fv)
+	}
+	if fv.GetFloatFinite() && (math.IsNaN(x) || math.IsInf(x, 0)) {
+		v.errorString(f, "float_finite", true, value.Interface(), "be finite", fv)
+	}
+
+	// Determine the real limits the same way the plugin does.
+	upperIsStrict := true
+	lowerIsStrict := true
+	if fv.FloatLt != nil && fv.FloatLte != nil {
+		strictLimit := fv.GetFloatLt()
+		if fv.FloatEpsilon != nil {
+			strictLimit += fv.GetFloatEpsilon()
+		}
+		if fv.GetFloatLte() < strictLimit {
+			upperIsStrict = false
+		}
+	} else if fv.FloatLte != nil {
+		upperIsStrict = false
+	}
+	if fv.FloatGt != nil && fv.FloatGte != nil {
+		strictLimit := fv.GetFloatGt()
+		if fv.FloatEpsilon != nil {
+			strictLimit -= fv.GetFloatEpsilon()
+		}
+		if fv.GetFloatGte() > strictLimit {
+			lowerIsStrict = false
+		}
+	} else if fv.FloatGte != nil {
+		lowerIsStrict = false
+	}
+
+	if fv.FloatGt != nil || fv.FloatGte != nil {
+		if lowerIsStrict {
+			errorStr := fmt.Sprintf(`be strictly greater than '%g'`, fv.GetFloatGt())
+			lhs := x
+			if fv.FloatEpsilon != nil {
+				errorStr += fmt.Sprintf(` with a tolerance of '%g'`, fv.GetFloatEpsilon())
+				lhs = round(x + epsilon)
+			}
+			if !(lhs > round(fv.GetFloatGt())) {
+				v.errorString(f, "float_gt", fv.GetFloatGt(), value.Interface(), errorStr, fv)
+			}
+		} else if !(x >= round(fv.GetFloatGte())) {
+			errorStr := fmt.Sprintf(`be greater than or equal to '%g'`, fv.GetFloatGte())
+			v.errorString(f, "float_gte", fv.GetFloatGte(), value.Interface(), errorStr, fv)
+		}
+	}
+	if fv.FloatLt != nil || fv.FloatLte != nil {
+		if upperIsStrict {
+			errorStr := fmt.Sprintf(`be strictly lower than '%g'`, fv.GetFloatLt())
+			lhs := x
+			if fv.FloatEpsilon != nil {
+				errorStr += fmt.Sprintf(` with a tolerance of '%g'`, fv.GetFloatEpsilon())
+				lhs = round(x - epsilon)
+			}
+			if !(lhs < round(fv.GetFloatLt())) {
+				v.errorString(f, "float_lt", fv.GetFloatLt(), value.Interface(), errorStr, fv)
+			}
+		} else if !(x <= round(fv.GetFloatLte())) {
+			errorStr := fmt.Sprintf(`be lower than or equal to '%g'`, fv.GetFloatLte())
+			v.errorString(f, "float_lte", fv.GetFloatLte(), value.Interface(), errorStr, fv)
+		}
+	}
+}
+
+func floatValue(value protoreflect.Value) float64 {
+	switch x := value.Interface().(type) {
+	case float32:
+		return float64(x)
+	case float64:
+		return x
+	case uint32, uint64:
+		return float64(value.Uint())
+	}
+	return float64(value.Int())
+}
+
+func (v *validation) string(f field, fd protoreflect.FieldDescriptor, value string, fv *validator.FieldValidator) {
+	if fv.Regex != nil {
+		re, err := v.cache.compileRegex(fd, fv.GetRegex())
+		if err != nil {
+			v.report(f.error(err))
+		} else if !re.MatchString(value) {
+			errorStr := "be a string conforming to regex " + strconv.Quote(fv.GetRegex())
+			v.errorString(f, "regex", fv.GetRegex(), value, errorStr, fv)
+		}
+	}
+	if fv.GetStringNotEmpty() && value == "" {
+		v.errorString(f, "string_not_empty", true, value, "not be an empty string", fv)
+	}
+	v.stringFormat(f, value, fv)
+	v.length(f, value, len(value), fv)
+	v.runeLength(f, value, fv)
+}
+
+func (v *validation) runeLength(f field, value string, fv *validator.FieldValidator) {
+	runes := int64(utf8.RuneCountInString(value))
+	if fv.RuneLengthGt != nil && !(runes > fv.GetRuneLengthGt()) {
+		errorStr := fmt.Sprintf(`be longer than '%d' characters`, fv.GetRuneLengthGt())
+		v.errorString(f, "rune_length_gt", fv.GetRuneLengthGt(), value, errorStr, fv)
+	}
+	if fv.RuneLengthLt != nil && !(runes < fv.GetRuneLengthLt()) {
+		errorStr := fmt.Sprintf(`be shorter than '%d' characters`, fv.GetRuneLengthLt())
+		v.errorString(f, "rune_length_lt", fv.GetRuneLengthLt(), value, errorStr, fv)
+	}
+	if fv.RuneLengthEq != nil && !(runes == fv.GetRuneLengthEq()) {
+		errorStr := fmt.Sprintf(`be '%d' characters long`, fv.GetRuneLengthEq())
+		v.errorString(f, "rune_length_eq", fv.GetRuneLengthEq(), value, errorStr, fv)
+	}
+	if fv.MinRunes != nil && !(runes >= fv.GetMinRunes()) {
+		errorStr := fmt.Sprintf(`be at least '%d' characters long`, fv.GetMinRunes())
+		v.errorString(f, "min_runes", fv.GetMinRunes(), value, errorStr, fv)
+	}
+	if fv.MaxRunes != nil && !(runes <= fv.GetMaxRunes()) {
+		errorStr := fmt.Sprintf(`be at most '%d' characters long`, fv.GetMaxRunes())
+		v.errorString(f, "max_runes", fv.GetMaxRunes(), value, errorStr, fv)
+	}
+}
+
+func (v *validation) length(f field, value interface{}, length int, fv *validator.FieldValidator) {
+	if fv.LengthGt != nil && !(int64(length) > fv.GetLengthGt()) {
+		errorStr := fmt.Sprintf(`length be greater than '%d'`, fv.GetLengthGt())
+		v.errorString(f, "length_gt", fv.GetLengthGt(), value, errorStr, fv)
+	}
+	if fv.LengthLt != nil && !(int64(length) < fv.GetLengthLt()) {
+		errorStr := fmt.Sprintf(`length be less than '%d'`, fv.GetLengthLt())
+		v.errorString(f, "length_lt", fv.GetLengthLt(), value, errorStr, fv)
+	}
+	if fv.LengthEq != nil && !(int64(length) == fv.GetLengthEq()) {
+		errorStr := fmt.Sprintf(`length be not equal '%d'`, fv.GetLengthEq())
+		v.errorString(f, "length_eq", fv.GetLengthEq(), value, errorStr, fv)
+	}
+	if fv.HexBytes != nil {
+		expected, errorStr := 2*int(fv.GetHexBytes()), fmt.Sprintf(`be the hex encoding of %d bytes`, fv.GetHexBytes())
+		if _, isBytes := value.([]byte); isBytes {
+			expected, errorStr = int(fv.GetHexBytes()), fmt.Sprintf(`be %d bytes long`, fv.GetHexBytes())
+		}
+		if length != expected {
+			v.errorString(f, "hex_bytes", uint64(fv.GetHexBytes()), value, errorStr, fv)
+		}
+	}
+	if data, isBytes := value.([]byte); isBytes && fv.BytesMessage != nil {
+		typeName := strings.TrimPrefix(fv.GetBytesMessage(), ".")
+		if _, err := validator.UnmarshalMessage(typeName, data); err != nil {
+			if fv.GetHumanError() == "" {
+				err = fmt.Errorf("value must be a %v message: %v", typeName, err)
+			} else {
+				err = errors.New(fv.GetHumanError())
+			}
+			v.constraintError(f, "bytes_message", typeName, value, err)
+		}
+	}
+}
+
+func (v *validation) repeatedCount(f field, list protoreflect.List, fv *validator.FieldValidator) {
+	if fv.RepeatedCountMin != nil && int64(list.Len()) < fv.GetRepeatedCountMin() {
+		errorStr := fmt.Sprint(`contain at least `, fv.GetRepeatedCountMin(), ` elements`)
+		v.errorString(f, "repeated_count_min", fv.GetRepeatedCountMin(), listValue(list), errorStr, fv)
+	}
+	if fv.RepeatedCountMax != nil && int64(list.Len()) > fv.GetRepeatedCountMax() {
+		errorStr := fmt.Sprint(`contain at most `, fv.GetRepeatedCountMax(), ` elements`)
+		v.errorString(f, "repeated_count_max", fv.GetRepeatedCountMax(), listValue(list), errorStr, fv)
+	}
+}
+
+// errorString reports a violation of the named constraint, using the same messages as the generated code.
+func (v *validation) errorString(f field, constraint string, constraintValue interface{}, value interface{}, specificError string, fv *validator.FieldValidator) {
+	var err error
+	if fv.GetHumanError() == "" {
+		err = fmt.Errorf("value '%v' must %s", value, specificError)
+	} else {
+		err = errors.New(fv.GetHumanError())
+	}
+	v.constraintError(f, constraint, constraintValue, value, err)
+}
+
+func (v *validation) constraintError(f field, constraint string, constraintValue interface{}, value interface{}, err error) {
+	v.report(f.error(validator.ConstraintError(f.constraintPrefix+constraint, constraintValue, value, err)))
+}
+
+// listValue converts a repeated field to a slice, so that it is formatted like the Go field in error messages.
+func listValue(list protoreflect.List) []interface{} {
+	values := make([]interface{}, list.Len())
+	for i := range values {
+		values[i] = goValue(list.Get(i))
+	}
+	return values
+}
+
+// mapValue converts a map field to a Go map, so that it is formatted like the Go field in error messages.
+func mapValue(m protoreflect.Map) map[interface{}]interface{} {
+	values := make(map[interface{}]interface{}, m.Len())
+	m.Range(func(key protoreflect.MapKey, value protoreflect.Value) bool {
+		values[key.Interface()] = goValue(value)
+		return true
+	})
+	return values
+}
+
+func goValue(value protoreflect.Value) interface{} {
+	if msg, ok := value.Interface().(protoreflect.Message); ok {
+		return msg.Interface()
+	}
+	return value.Interface()
+}
+
+type regexKey struct {
+	fd   protoreflect.FieldDescriptor
+	expr string
+}
+
+// compileRegex compiles the regex of the field.
+func (c *Validator) compileRegex(fd protoreflect.FieldDescriptor, expr string) (*regexp.Regexp, error) {
+	key := regexKey{fd: fd, expr: expr}
+	if re, ok := c.regexes.Load(key); ok {
+		return re.(*regexp.Regexp), nil
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, err
+	}
+	if c.cacheable(fd) {
+		c.regexes.Store(key, re)
+	}
+	return re, nil
+}
+
+// messageValidator returns the (validator.message) option of the message, or nil if it has none.
+func (c *Validator) messageValidator(md protoreflect.MessageDescriptor) *validator.MessageValidator {
+	if mv, ok := c.messageValidators.Load(md); ok {
+		return mv.(*validator.MessageValidator)
+	}
+	mv, _ := loadExtension(md.Options(), &descriptor.MessageOptions{}, validator.E_Message).(*validator.MessageValidator)
+	if c.cacheable(md) {
+		c.messageValidators.Store(md, mv)
+	}
+	return mv
+}
+
+// oneofValidator returns the (validator.oneof) option of the oneof, or nil if it has none.
+func (c *Validator) oneofValidator(od protoreflect.OneofDescriptor) *validator.OneofValidator {
+	if ov, ok := c.oneofValidators.Load(od); ok {
+		return ov.(*validator.OneofValidator)
+	}
+	ov, _ := loadExtension(od.Options(), &descriptor.OneofOptions{}, validator.E_Oneof).(*validator.OneofValidator)
+	if c.cacheable(od) {
+		c.oneofValidators.Store(od, ov)
+	}
+	return ov
+}
+
+// fieldValidator returns the (validator.field) option of the field, or nil if it has none.
+func (c *Validator) fieldValidator(fd protoreflect.FieldDescriptor) *validator.FieldValidator {
+	if fv, ok := c.fieldValidators.Load(fd); ok {
+		return fv.(*validator.FieldValidator)
+	}
+	fv, _ := loadExtension(fd.Options(), &descriptor.FieldOptions{}, validator.E_Field).(*validator.FieldValidator)
+	fv = c.withFileDefaults(fd, fv)
+	if c.cacheable(fd) {
+		c.fieldValidators.Store(fd, fv)
+	}
+	return fv
+}
+
+// fileValidator returns the (validator.file) option of the file, or nil if it has none.
+func (c *Validator) fileValidator(fd protoreflect.FileDescriptor) *validator.FileValidator {
+	if fv, ok := c.fileValidators.Load(fd); ok {
+		return fv.(*validator.FileValidator)
+	}
+	fv, _ := loadExtension(fd.Options(), &descriptor.FileOptions{}, validator.E_File).(*validator.FileValidator)
+	if c.cacheable(fd) {
+		c.fileValidators.Store(fd, fv)
+	}
+	return fv
+}
+
+// withFileDefaults applies the defaults of the (validator.file) option to the constraints of the float values of
+// the field, like the plugin does.
+func (c *Validator) withFileDefaults(fd protoreflect.FieldDescriptor, fv *validator.FieldValidator) *validator.FieldValidator {
+	if fd.ParentFile() == nil {
+		return fv
+	}
+	defaults := c.fileValidator(fd.ParentFile())
+	if defaults == nil {
+		return fv
+	}
+	valueField := fd
+	if fd.IsMap() {
+		valueField = fd.MapValue()
+	}
+	if valueField.Message() != nil && wrapperTypes[valueField.Message().FullName()] {
+		valueField = valueField.Message().Fields().ByName("value")
+	}
+	if kind := valueField.Kind(); kind != protoreflect.FloatKind && kind != protoreflect.DoubleKind {
+		return fv
+	}
+	if fv == nil {
+		fv = &validator.FieldValidator{}
+	} else {
+		fv = gogoproto.Clone(fv).(*validator.FieldValidator)
+	}
+	target := fv
+	if fd.IsMap() {
+		if fv.MapValue == nil {
+			fv.MapValue = &validator.FieldValidator{}
+		}
+		target = fv.MapValue
+	}
+	if target.FloatFinite == nil {
+		target.FloatFinite = defaults.FloatFinite
+	}
+	if target.FloatNotNan == nil {
+		target.FloatNotNan = defaults.FloatNotNan
+	}
+	return fv
+}
+
+// loadExtension reads a validator option by converting the options to their gogo counterpart, on which the
+// validator extensions are registered.
+func loadExtension(options proto.Message, gogoOptions gogoproto.Message, extension *gogoproto.ExtensionDesc) interface{} {
+	b, err := proto.Marshal(options)
+	if err != nil || len(b) == 0 {
+		return nil
+	}
+	if err := gogoproto.Unmarshal(b, gogoOptions); err != nil {
+		return nil
+	}
+	v, err := gogoproto.GetExtension(gogoOptions, extension)
+	if err != nil {
+		return nil
+	}
+	return v
+}
+
+// methodNames are the names of the methods generated on messages, which fields are renamed to avoid.
+var methodNames = map[string]bool{
+	"Reset": true, "String": true, "ProtoMessage": true, "Marshal": true, "Unmarshal": true,
+	"ExtensionRangeArray": true, "ExtensionMap": true, "Descriptor": true, "MarshalTo": true,
+	"Equal": true, "VerboseEqual": true, "GoString": true, "ProtoSize": true, "Size": true,
+}
+
+// goFieldName returns the Go name of the field, as used in the errors of the generated code.
+func goFieldName(fd protoreflect.FieldDescriptor) string {
+	name := generator.CamelCase(string(fd.Name()))
+	if methodNames[name] {
+		return name + "_"
+	}
+	return name
+}
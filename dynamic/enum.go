@@ -0,0 +1,63 @@
+// Copyright 2016 Michal Witkowski. All Rights Reserved.
+// See LICENSE for licensing terms.
+
+package dynamic
+
+import (
+	"fmt"
+
+	"github.com/mwitkow/go-proto-validators"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+)
+
+// enum checks the constraints of an enum value.
+func (v *validation) enum(f field, ed protoreflect.EnumDescriptor, number protoreflect.EnumNumber, fv *validator.FieldValidator) {
+	value := enumValue(ed, number)
+	if fv.GetEnumDefinedOnly() && ed.Values().ByNumber(number) == nil {
+		v.errorString(f, "enum_defined_only", true, value, "be a defined enum value", fv)
+	}
+	if len(fv.EnumIn) > 0 && !containsNumber(fv.EnumIn, number) {
+		errorStr := fmt.Sprintf(`be one of the values %v`, enumValueNames(ed, fv.EnumIn))
+		v.errorString(f, "enum_in", fv.EnumIn, value, errorStr, fv)
+	}
+	if len(fv.EnumNotIn) > 0 && containsNumber(fv.EnumNotIn, number) {
+		errorStr := fmt.Sprintf(`not be one of the values %v`, enumValueNames(ed, fv.EnumNotIn))
+		v.errorString(f, "enum_not_in", fv.EnumNotIn, value, errorStr, fv)
+	}
+	if fv.GetEnumNotZero() && number == 0 {
+		v.errorString(f, "enum_not_zero", true, value, "not be the zero value", fv)
+	}
+}
+
+// enumValue returns the Go value of an enum number, which is printed with its name like in the generated code.
+func enumValue(ed protoreflect.EnumDescriptor, number protoreflect.EnumNumber) interface{} {
+	if et, err := protoregistry.GlobalTypes.FindEnumByName(ed.FullName()); err == nil {
+		return et.New(number)
+	}
+	if value := ed.Values().ByNumber(number); value != nil {
+		return value.Name()
+	}
+	return number
+}
+
+// enumValueNames returns the names of the enum values with the given numbers, or the number if there is none.
+func enumValueNames(ed protoreflect.EnumDescriptor, numbers []int32) []string {
+	names := make([]string, len(numbers))
+	for i, number := range numbers {
+		names[i] = fmt.Sprint(number)
+		if value := ed.Values().ByNumber(protoreflect.EnumNumber(number)); value != nil {
+			names[i] = string(value.Name())
+		}
+	}
+	return names
+}
+
+func containsNumber(numbers []int32, number protoreflect.EnumNumber) bool {
+	for _, n := range numbers {
+		if protoreflect.EnumNumber(n) == number {
+			return true
+		}
+	}
+	return false
+}
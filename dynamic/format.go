@@ -0,0 +1,59 @@
+// Copyright 2016 Michal Witkowski. All Rights Reserved.
+// See LICENSE for licensing terms.
+
+package dynamic
+
+import (
+	"fmt"
+
+	"github.com/mwitkow/go-proto-validators"
+)
+
+// stringFormats are the formats of string fields, with the helpers that the generated code calls to check them.
+var stringFormats = []struct {
+	constraint  string
+	set         func(fv *validator.FieldValidator) bool
+	check       func(s string) bool
+	description string
+}{
+	{"ip", (*validator.FieldValidator).GetIp, validator.IsIP, "be an IP address"},
+	{"ipv4", (*validator.FieldValidator).GetIpv4, validator.IsIPv4, "be an IPv4 address"},
+	{"ipv6", (*validator.FieldValidator).GetIpv6, validator.IsIPv6, "be an IPv6 address"},
+	{"cidr", (*validator.FieldValidator).GetCidr, validator.IsCIDR, "be an IP network in CIDR notation"},
+	{"hostname", (*validator.FieldValidator).GetHostname, validator.IsHostname, "be a hostname"},
+	{"host_port", (*validator.FieldValidator).GetHostPort, validator.IsHostPort, "be a host and port"},
+	{"mac", (*validator.FieldValidator).GetMac, validator.IsMAC, "be a MAC address"},
+	{"uuid", (*validator.FieldValidator).GetUuid, validator.IsUUID, "be a UUID"},
+	{"ulid", (*validator.FieldValidator).GetUlid, validator.IsULID, "be a ULID"},
+	{"hex", (*validator.FieldValidator).GetHex, validator.IsHex, "be a hex string"},
+	{"email", (*validator.FieldValidator).GetEmail, validator.IsEmail, "be an email address"},
+	{"uri", (*validator.FieldValidator).GetUri, validator.IsURI, "be a URI"},
+	{"uri_ref", (*validator.FieldValidator).GetUriRef, validator.IsURIRef, "be a URI reference"},
+	{"url", (*validator.FieldValidator).GetUrl, validator.IsURL, "be a URL"},
+	{"utf8", (*validator.FieldValidator).GetUtf8, validator.IsUTF8, "be valid UTF-8"},
+	{"base64", (*validator.FieldValidator).GetBase64, validator.IsBase64, "be base64"},
+	{"base64url", (*validator.FieldValidator).GetBase64Url, validator.IsBase64URL, "be URL-safe base64"},
+	{"json", (*validator.FieldValidator).GetJson, validator.IsJSON, "be JSON"},
+}
+
+// stringFormat checks the string formats set in fv.
+func (v *validation) stringFormat(f field, value string, fv *validator.FieldValidator) {
+	for _, format := range stringFormats {
+		if format.set(fv) && !format.check(value) {
+			v.errorString(f, format.constraint, true, value, format.description, fv)
+		}
+	}
+	if fv.UuidVersion != nil && !validator.IsUUIDVersion(value, int(fv.GetUuidVersion())) {
+		errorStr := fmt.Sprintf("be a version %d UUID", fv.GetUuidVersion())
+		v.errorString(f, "uuid_version", uint64(fv.GetUuidVersion()), value, errorStr, fv)
+	}
+	// Only uri_ref allows relative references, which don't have a scheme.
+	relative := fv.GetUriRef() && !validator.IsURI(value)
+	if len(fv.UriSchemes) > 0 && !relative && !validator.HasURIScheme(value, fv.UriSchemes...) {
+		errorStr := fmt.Sprintf("have one of the schemes %v", fv.UriSchemes)
+		v.errorString(f, "uri_schemes", fv.UriSchemes, value, errorStr, fv)
+	}
+	if fv.GetUriNoUserinfo() && validator.HasURIUserinfo(value) {
+		v.errorString(f, "uri_no_userinfo", true, value, "not contain userinfo", fv)
+	}
+}
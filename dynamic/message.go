@@ -0,0 +1,228 @@
+// Copyright 2016 Michal Witkowski. All Rights Reserved.
+// See LICENSE for licensing terms.
+
+package dynamic
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/mwitkow/go-proto-validators"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// comparisons are the comparisons of a validator.FieldComparison, in the order of the generated checks.
+var comparisons = []struct {
+	constraint  string
+	description string
+	otherField  func(fc *validator.FieldComparison) *string
+	holds       func(cmp int) bool
+}{
+	{"lt", "less than", func(fc *validator.FieldComparison) *string { return fc.Lt }, func(cmp int) bool { return cmp < 0 }},
+	{"lte", "less than or equal to", func(fc *validator.FieldComparison) *string { return fc.Lte }, func(cmp int) bool { return cmp <= 0 }},
+	{"gt", "greater than", func(fc *validator.FieldComparison) *string { return fc.Gt }, func(cmp int) bool { return cmp > 0 }},
+	{"gte", "greater than or equal to", func(fc *validator.FieldComparison) *string { return fc.Gte }, func(cmp int) bool { return cmp >= 0 }},
+	{"eq", "equal to", func(fc *validator.FieldComparison) *string { return fc.Eq }, func(cmp int) bool { return cmp == 0 }},
+	{"neq", "different from", func(fc *validator.FieldComparison) *string { return fc.Neq }, func(cmp int) bool { return cmp != 0 }},
+}
+
+// messageRules checks the (validator.message) option of the message, after the constraints of its fields.
+func (v *validation) messageRules(msg protoreflect.Message) {
+	mv := v.cache.messageValidator(msg.Descriptor())
+	for _, fc := range mv.GetCompare() {
+		for _, c := range comparisons {
+			if c.otherField(fc) == nil || v.done() {
+				continue
+			}
+			fd, err := messageField(msg.Descriptor(), fc.GetField())
+			if err != nil {
+				v.report(err)
+				continue
+			}
+			other, err := messageField(msg.Descriptor(), *c.otherField(fc))
+			if err != nil {
+				v.report(err)
+				continue
+			}
+			if !isPresent(msg, fd) || !isPresent(msg, other) {
+				continue
+			}
+			cmp, err := compareFields(fd, msg.Get(fd), other, msg.Get(other))
+			if err != nil {
+				v.report(err)
+				continue
+			}
+			if c.holds(cmp) {
+				continue
+			}
+			errorStr := fmt.Sprint(`value must be `, c.description, ` the value of `, other.Name())
+			if fc.GetHumanError() != "" {
+				errorStr = fc.GetHumanError()
+			}
+			f := field{goName: goFieldName(fd), protoName: string(fd.Name())}
+			v.constraintError(f, "compare."+c.constraint, string(other.Name()), goValue(msg.Get(fd)), errors.New(errorStr))
+		}
+	}
+	for _, requirement := range mv.GetRequiredIf() {
+		if v.done() {
+			return
+		}
+		fd, err := messageField(msg.Descriptor(), requirement.GetField())
+		if err != nil {
+			v.report(err)
+			continue
+		}
+		ifField, err := messageField(msg.Descriptor(), requirement.GetIfField())
+		if err != nil {
+			v.report(err)
+			continue
+		}
+		var applies bool
+		var errorStr string
+		if requirement.IfEquals == nil {
+			applies = msg.Has(ifField)
+			errorStr = fmt.Sprint(`value must be set when `, ifField.Name(), ` is set`)
+		} else {
+			equal, err := equalsLiteral(ifField, msg.Get(ifField), requirement.GetIfEquals())
+			if err != nil {
+				v.report(err)
+				continue
+			}
+			applies = isPresent(msg, ifField) && equal
+			errorStr = fmt.Sprint(`value must be set when `, ifField.Name(), ` is '`, requirement.GetIfEquals(), `'`)
+		}
+		if !applies || msg.Has(fd) {
+			continue
+		}
+		if requirement.GetHumanError() != "" {
+			errorStr = requirement.GetHumanError()
+		}
+		f := field{goName: goFieldName(fd), protoName: string(fd.Name())}
+		v.constraintError(f, "required_if", string(ifField.Name()), nil, errors.New(errorStr))
+	}
+	v.celMessage(msg, mv)
+}
+
+// messageField returns the field referenced by the (validator.message) option using its .proto name.
+func messageField(md protoreflect.MessageDescriptor, name string) (protoreflect.FieldDescriptor, error) {
+	fd := md.Fields().ByName(protoreflect.Name(name))
+	if fd == nil {
+		return nil, fmt.Errorf("message %v has no field %q, referenced by its validator.message option", md.FullName(), name)
+	}
+	if oneOf := fd.ContainingOneof(); oneOf != nil && !oneOf.IsSynthetic() {
+		return nil, fmt.Errorf("field %v is part of a oneof, it can't be referenced by the validator.message option", fd.FullName())
+	}
+	return fd, nil
+}
+
+// isPresent returns whether the field holds a value that can be compared, like in the generated code.
+func isPresent(msg protoreflect.Message, fd protoreflect.FieldDescriptor) bool {
+	return !fd.HasPresence() || msg.Has(fd)
+}
+
+// compareFields returns -1, 0 or 1 depending on whether the value of fd is less than, equal to or greater than the
+// value of other.
+func compareFields(fd protoreflect.FieldDescriptor, value protoreflect.Value, other protoreflect.FieldDescriptor, otherValue protoreflect.Value) (int, error) {
+	if fd.IsList() || other.IsList() || fd.IsMap() || other.IsMap() || typeName(fd) != typeName(other) {
+		return 0, fmt.Errorf("fields %v and %v can't be compared", fd.FullName(), other.FullName())
+	}
+	switch fd.Kind() {
+	case protoreflect.MessageKind:
+		switch fd.Message().FullName() {
+		case "google.protobuf.Timestamp", "google.protobuf.Duration":
+			return compareSecondsNanos(value.Message(), otherValue.Message()), nil
+		}
+	case protoreflect.BoolKind:
+		if value.Bool() == otherValue.Bool() {
+			return 0, nil
+		}
+		return 1, nil
+	case protoreflect.EnumKind:
+		return ordering(value.Enum() < otherValue.Enum(), value.Enum() > otherValue.Enum()), nil
+	case protoreflect.StringKind:
+		return ordering(value.String() < otherValue.String(), value.String() > otherValue.String()), nil
+	case protoreflect.FloatKind, protoreflect.DoubleKind:
+		return ordering(value.Float() < otherValue.Float(), value.Float() > otherValue.Float()), nil
+	case protoreflect.Uint32Kind, protoreflect.Uint64Kind, protoreflect.Fixed32Kind, protoreflect.Fixed64Kind:
+		return ordering(value.Uint() < otherValue.Uint(), value.Uint() > otherValue.Uint()), nil
+	case protoreflect.Int32Kind, protoreflect.Int64Kind, protoreflect.Sint32Kind, protoreflect.Sint64Kind,
+		protoreflect.Sfixed32Kind, protoreflect.Sfixed64Kind:
+		return ordering(value.Int() < otherValue.Int(), value.Int() > otherValue.Int()), nil
+	}
+	return 0, fmt.Errorf("fields %v and %v can't be compared", fd.FullName(), other.FullName())
+}
+
+// typeName returns the kind of a field, qualified by the name of its message or enum type.
+func typeName(fd protoreflect.FieldDescriptor) string {
+	switch {
+	case fd.Message() != nil:
+		return fd.Kind().String() + " " + string(fd.Message().FullName())
+	case fd.Enum() != nil:
+		return fd.Kind().String() + " " + string(fd.Enum().FullName())
+	}
+	return fd.Kind().String()
+}
+
+// ordering returns -1, 0 or 1 depending on whether a value is less than, equal to or greater than another.
+func ordering(less bool, greater bool) int {
+	if less {
+		return -1
+	} else if greater {
+		return 1
+	}
+	return 0
+}
+
+// compareSecondsNanos compares Timestamp or Duration messages, like validator.CompareTimestamps.
+func compareSecondsNanos(a, b protoreflect.Message) int {
+	fields := a.Descriptor().Fields()
+	seconds, nanos := fields.ByName("seconds"), fields.ByName("nanos")
+	aSeconds, bSeconds := a.Get(seconds).Int(), b.Get(seconds).Int()
+	aNanos, bNanos := a.Get(nanos).Int(), b.Get(nanos).Int()
+	return ordering(aSeconds < bSeconds || aSeconds == bSeconds && aNanos < bNanos, aSeconds > bSeconds || aSeconds == bSeconds && aNanos > bNanos)
+}
+
+// equalsLiteral returns whether the value of a scalar field equals the given value, written like in the .proto file.
+func equalsLiteral(fd protoreflect.FieldDescriptor, value protoreflect.Value, literal string) (bool, error) {
+	var equal bool
+	var err error
+	switch fd.Kind() {
+	case protoreflect.EnumKind:
+		if enumValue := fd.Enum().Values().ByName(protoreflect.Name(literal)); enumValue != nil {
+			return value.Enum() == enumValue.Number(), nil
+		}
+		var n int64
+		n, err = strconv.ParseInt(literal, 10, 32)
+		equal = int64(value.Enum()) == n
+	case protoreflect.StringKind:
+		equal = value.String() == literal
+	case protoreflect.BoolKind:
+		var b bool
+		b, err = strconv.ParseBool(literal)
+		equal = value.Bool() == b
+	case protoreflect.FloatKind, protoreflect.DoubleKind:
+		var f float64
+		f, err = strconv.ParseFloat(literal, 64)
+		if fd.Kind() == protoreflect.FloatKind {
+			// The generated code compares float fields using float32 arithmetic.
+			f = float64(float32(f))
+		}
+		equal = value.Float() == f
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind, protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		var n uint64
+		n, err = strconv.ParseUint(literal, 10, 64)
+		equal = value.Uint() == n
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind,
+		protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		var n int64
+		n, err = strconv.ParseInt(literal, 10, 64)
+		equal = value.Int() == n
+	default:
+		err = errors.New("only numbers, enums, strings and booleans can be compared to a value")
+	}
+	if err != nil {
+		return false, fmt.Errorf("field %v can't be compared to %q: %v", fd.FullName(), literal, err)
+	}
+	return equal, nil
+}
@@ -0,0 +1,91 @@
+// Copyright 2016 Michal Witkowski. All Rights Reserved.
+// See LICENSE for licensing terms.
+
+package dynamic
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/mwitkow/go-proto-validators"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// timeValue checks the constraints of a google.protobuf.Timestamp or google.protobuf.Duration message.
+func (v *validation) timeValue(f field, msg protoreflect.Message, fv *validator.FieldValidator) {
+	if fv == nil {
+		return
+	}
+	switch msg.Descriptor().FullName() {
+	case "google.protobuf.Timestamp":
+		v.timestamp(f, validator.AsTime(messageSecondsNanos(msg)), fv)
+	case "google.protobuf.Duration":
+		v.duration(f, validator.AsDuration(messageSecondsNanos(msg)), fv)
+	}
+}
+
+func messageSecondsNanos(msg protoreflect.Message) secondsNanos {
+	fields := msg.Descriptor().Fields()
+	return secondsNanos{seconds: msg.Get(fields.ByName("seconds")).Int(), nanos: int32(msg.Get(fields.ByName("nanos")).Int())}
+}
+
+// secondsNanos holds the fields of a Timestamp or Duration message, like their Go types.
+type secondsNanos struct {
+	seconds int64
+	nanos   int32
+}
+
+func (t secondsNanos) GetSeconds() int64 { return t.seconds }
+func (t secondsNanos) GetNanos() int32   { return t.nanos }
+
+func (v *validation) timestamp(f field, t time.Time, fv *validator.FieldValidator) {
+	if fv.GetTimestampLtNow() && !t.Before(time.Now()) {
+		v.errorString(f, "timestamp_lt_now", true, t, "be in the past", fv)
+	}
+	if fv.GetTimestampGtNow() && !t.After(time.Now()) {
+		v.errorString(f, "timestamp_gt_now", true, t, "be in the future", fv)
+	}
+	if fv.TimestampWithin != nil {
+		if d, err := time.ParseDuration(fv.GetTimestampWithin()); err != nil {
+			v.report(f.error(err))
+		} else if !validator.TimeWithin(t, d) {
+			errorStr := fmt.Sprintf(`be within '%v' of now`, fv.GetTimestampWithin())
+			v.errorString(f, "timestamp_within", fv.GetTimestampWithin(), t, errorStr, fv)
+		}
+	}
+	if fv.TimestampGt != nil {
+		if bound, err := time.Parse(time.RFC3339Nano, fv.GetTimestampGt()); err != nil {
+			v.report(f.error(err))
+		} else if !t.After(bound) {
+			errorStr := fmt.Sprintf(`be after '%v'`, fv.GetTimestampGt())
+			v.errorString(f, "timestamp_gt", fv.GetTimestampGt(), t, errorStr, fv)
+		}
+	}
+	if fv.TimestampLt != nil {
+		if bound, err := time.Parse(time.RFC3339Nano, fv.GetTimestampLt()); err != nil {
+			v.report(f.error(err))
+		} else if !t.Before(bound) {
+			errorStr := fmt.Sprintf(`be before '%v'`, fv.GetTimestampLt())
+			v.errorString(f, "timestamp_lt", fv.GetTimestampLt(), t, errorStr, fv)
+		}
+	}
+}
+
+func (v *validation) duration(f field, d time.Duration, fv *validator.FieldValidator) {
+	if fv.DurationGt != nil {
+		if bound, err := time.ParseDuration(fv.GetDurationGt()); err != nil {
+			v.report(f.error(err))
+		} else if !(d > bound) {
+			errorStr := fmt.Sprintf(`be greater than '%v'`, fv.GetDurationGt())
+			v.errorString(f, "duration_gt", fv.GetDurationGt(), d, errorStr, fv)
+		}
+	}
+	if fv.DurationLt != nil {
+		if bound, err := time.ParseDuration(fv.GetDurationLt()); err != nil {
+			v.report(f.error(err))
+		} else if !(d < bound) {
+			errorStr := fmt.Sprintf(`be less than '%v'`, fv.GetDurationLt())
+			v.errorString(f, "duration_lt", fv.GetDurationLt(), d, errorStr, fv)
+		}
+	}
+}
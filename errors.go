@@ -0,0 +1,39 @@
+// Copyright 2016 Michal Witkowski. All Rights Reserved.
+// See LICENSE for licensing terms.
+
+package validator
+
+import "strings"
+
+// Errors is a list of validation errors, as returned by the generated ValidateAll methods.
+type Errors []error
+
+func (e Errors) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// Unwrap returns the errors in the list, so that errors.Is and errors.As can inspect them.
+func (e Errors) Unwrap() []error {
+	return e
+}
+
+// Add appends err to the list, flattening it if it is a list of Errors itself.
+func (e *Errors) Add(err error) {
+	if errs, ok := err.(Errors); ok {
+		*e = append(*e, errs...)
+		return
+	}
+	*e = append(*e, err)
+}
+
+// ErrorOrNil returns nil if the list is empty, and the list itself otherwise.
+func (e Errors) ErrorOrNil() error {
+	if len(e) == 0 {
+		return nil
+	}
+	return e
+}
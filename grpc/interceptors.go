@@ -0,0 +1,86 @@
+// Copyright 2016 Michal Witkowski. All Rights Reserved.
+// See LICENSE for licensing terms.
+
+/*
+Package grpc_validator provides gRPC interceptors that call the generated Validate methods.
+
+Server interceptors validate every received request message, client interceptors validate every request message
+before it is sent. Messages that fail validation are rejected with a codes.InvalidArgument status, messages without
+a Validate method are passed through. The status carries a google.rpc.BadRequest detail describing the offending
+fields, see validator.BadRequestStatus.
+*/
+package grpc_validator
+
+import (
+	"context"
+
+	"github.com/mwitkow/go-proto-validators"
+	"google.golang.org/grpc"
+)
+
+func validate(msg interface{}) error {
+	if err := validator.CallValidatorIfExists(msg); err != nil {
+		return validator.BadRequestStatus(err).Err()
+	}
+	return nil
+}
+
+// UnaryServerInterceptor returns a unary server interceptor that validates incoming requests.
+func UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if err := validate(req); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamServerInterceptor returns a stream server interceptor that validates every message received on the stream.
+func StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		return handler(srv, &validatingServerStream{ServerStream: stream})
+	}
+}
+
+type validatingServerStream struct {
+	grpc.ServerStream
+}
+
+func (s *validatingServerStream) RecvMsg(m interface{}) error {
+	if err := s.ServerStream.RecvMsg(m); err != nil {
+		return err
+	}
+	return validate(m)
+}
+
+// UnaryClientInterceptor returns a unary client interceptor that validates outgoing requests.
+func UnaryClientInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		if err := validate(req); err != nil {
+			return err
+		}
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+}
+
+// StreamClientInterceptor returns a stream client interceptor that validates every message sent on the stream.
+func StreamClientInterceptor() grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		stream, err := streamer(ctx, desc, cc, method, opts...)
+		if err != nil {
+			return nil, err
+		}
+		return &validatingClientStream{ClientStream: stream}, nil
+	}
+}
+
+type validatingClientStream struct {
+	grpc.ClientStream
+}
+
+func (s *validatingClientStream) SendMsg(m interface{}) error {
+	if err := validate(m); err != nil {
+		return err
+	}
+	return s.ClientStream.SendMsg(m)
+}
@@ -0,0 +1,180 @@
+// Copyright 2016 Michal Witkowski. All Rights Reserved.
+// See LICENSE for licensing terms.
+
+package grpc_validator
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"sync/atomic"
+	"testing"
+
+	"github.com/mwitkow/go-proto-validators"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+// testMessage is a proto message with a Validate method, requiring its value to be non-empty.
+type testMessage struct {
+	*wrapperspb.StringValue
+}
+
+func newTestMessage(value string) *testMessage {
+	return &testMessage{StringValue: wrapperspb.String(value)}
+}
+
+func (m *testMessage) Validate() error {
+	if m.GetValue() == "" {
+		return validator.FieldError("Value", errors.New("must not be empty"))
+	}
+	return nil
+}
+
+type testServer struct {
+	received int32
+}
+
+var testServiceDesc = grpc.ServiceDesc{
+	ServiceName: "validator.test.Test",
+	HandlerType: (*interface{})(nil),
+	Methods: []grpc.MethodDesc{{
+		MethodName: "Unary",
+		Handler: func(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+			in := newTestMessage("")
+			if err := dec(in); err != nil {
+				return nil, err
+			}
+			handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+				atomic.AddInt32(&srv.(*testServer).received, 1)
+				return req, nil
+			}
+			if interceptor == nil {
+				return handler(ctx, in)
+			}
+			return interceptor(ctx, in, &grpc.UnaryServerInfo{Server: srv, FullMethod: "/validator.test.Test/Unary"}, handler)
+		},
+	}},
+	Streams: []grpc.StreamDesc{{
+		StreamName:    "Stream",
+		ServerStreams: true,
+		ClientStreams: true,
+		Handler: func(srv interface{}, stream grpc.ServerStream) error {
+			for {
+				in := newTestMessage("")
+				if err := stream.RecvMsg(in); err == io.EOF {
+					return nil
+				} else if err != nil {
+					return err
+				}
+				atomic.AddInt32(&srv.(*testServer).received, 1)
+				if err := stream.SendMsg(in); err != nil {
+					return err
+				}
+			}
+		},
+	}},
+}
+
+func startServer(t *testing.T, serverOpts []grpc.ServerOption, dialOpts ...grpc.DialOption) (*testServer, *grpc.ClientConn) {
+	lis := bufconn.Listen(1024 * 1024)
+	srv := &testServer{}
+	server := grpc.NewServer(serverOpts...)
+	server.RegisterService(&testServiceDesc, srv)
+	go server.Serve(lis)
+	t.Cleanup(server.Stop)
+
+	dialOpts = append(dialOpts,
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	conn, err := grpc.NewClient("passthrough:///bufconn", dialOpts...)
+	if err != nil {
+		t.Fatalf("failed to dial test server: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return srv, conn
+}
+
+func openStream(t *testing.T, conn *grpc.ClientConn) grpc.ClientStream {
+	stream, err := conn.NewStream(context.Background(), &testServiceDesc.Streams[0], "/validator.test.Test/Stream")
+	if err != nil {
+		t.Fatalf("failed to open stream: %v", err)
+	}
+	return stream
+}
+
+func TestUnaryServerInterceptor(t *testing.T) {
+	srv, conn := startServer(t, []grpc.ServerOption{grpc.UnaryInterceptor(UnaryServerInterceptor())})
+
+	reply := newTestMessage("")
+	err := conn.Invoke(context.Background(), "/validator.test.Test/Unary", newTestMessage("valid"), reply)
+	assert.NoError(t, err, "valid request must pass")
+	assert.Equal(t, "valid", reply.GetValue())
+
+	err = conn.Invoke(context.Background(), "/validator.test.Test/Unary", newTestMessage(""), reply)
+	assert.Equal(t, codes.InvalidArgument, status.Code(err), "invalid request must be rejected")
+	assert.Equal(t, "invalid field Value: must not be empty", status.Convert(err).Message())
+	if details := status.Convert(err).Details(); assert.Len(t, details, 1) {
+		badRequest := details[0].(*errdetails.BadRequest)
+		assert.Equal(t, "Value", badRequest.GetFieldViolations()[0].GetField())
+		assert.Equal(t, "must not be empty", badRequest.GetFieldViolations()[0].GetDescription())
+	}
+	assert.EqualValues(t, 1, atomic.LoadInt32(&srv.received), "invalid request must not reach the handler")
+}
+
+func TestStreamServerInterceptor(t *testing.T) {
+	srv, conn := startServer(t, []grpc.ServerOption{grpc.StreamInterceptor(StreamServerInterceptor())})
+	stream := openStream(t, conn)
+
+	assert.NoError(t, stream.SendMsg(newTestMessage("valid")))
+	reply := newTestMessage("")
+	assert.NoError(t, stream.RecvMsg(reply), "valid message must pass")
+	assert.Equal(t, "valid", reply.GetValue())
+
+	assert.NoError(t, stream.SendMsg(newTestMessage("")))
+	err := stream.RecvMsg(reply)
+	assert.Equal(t, codes.InvalidArgument, status.Code(err), "invalid message must be rejected")
+	assert.EqualValues(t, 1, atomic.LoadInt32(&srv.received), "invalid message must not reach the handler")
+}
+
+func TestUnaryClientInterceptor(t *testing.T) {
+	srv, conn := startServer(t, nil, grpc.WithUnaryInterceptor(UnaryClientInterceptor()))
+
+	reply := newTestMessage("")
+	err := conn.Invoke(context.Background(), "/validator.test.Test/Unary", newTestMessage("valid"), reply)
+	assert.NoError(t, err, "valid request must be sent")
+
+	err = conn.Invoke(context.Background(), "/validator.test.Test/Unary", newTestMessage(""), reply)
+	assert.Equal(t, codes.InvalidArgument, status.Code(err), "invalid request must not be sent")
+	assert.EqualValues(t, 1, atomic.LoadInt32(&srv.received), "invalid request must not reach the server")
+}
+
+func TestStreamClientInterceptor(t *testing.T) {
+	srv, conn := startServer(t, nil, grpc.WithStreamInterceptor(StreamClientInterceptor()))
+	stream := openStream(t, conn)
+
+	assert.NoError(t, stream.SendMsg(newTestMessage("valid")), "valid message must be sent")
+	assert.NoError(t, stream.RecvMsg(newTestMessage("")))
+
+	err := stream.SendMsg(newTestMessage(""))
+	assert.Equal(t, codes.InvalidArgument, status.Code(err), "invalid message must not be sent")
+	assert.NoError(t, stream.CloseSend())
+	assert.Equal(t, io.EOF, stream.RecvMsg(newTestMessage("")))
+	assert.EqualValues(t, 1, atomic.LoadInt32(&srv.received), "invalid message must not reach the server")
+}
+
+func TestPassesMessagesWithoutValidator(t *testing.T) {
+	_, conn := startServer(t, nil, grpc.WithUnaryInterceptor(UnaryClientInterceptor()))
+
+	reply := newTestMessage("")
+	err := conn.Invoke(context.Background(), "/validator.test.Test/Unary", wrapperspb.String(""), reply)
+	assert.NoError(t, err, "messages without Validate method must pass")
+}
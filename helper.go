@@ -3,13 +3,26 @@
 
 package validator
 
-import "strings"
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
 
 // Validator is a general interface that allows a message to be validated.
 type Validator interface {
 	Validate() error
 }
 
+// AllValidator is a general interface that allows a message to be validated, reporting all violations at once.
+type AllValidator interface {
+	ValidateAll() error
+}
+
 func CallValidatorIfExists(candidate interface{}) error {
 	if validator, ok := candidate.(Validator); ok {
 		return validator.Validate()
@@ -17,23 +30,208 @@ func CallValidatorIfExists(candidate interface{}) error {
 	return nil
 }
 
-type fieldError struct {
-	fieldStack []string
-	nestedErr  error
+// CallValidateAllIfExists calls ValidateAll on the candidate, falling back to Validate if only that is available.
+func CallValidateAllIfExists(candidate interface{}) error {
+	if validator, ok := candidate.(AllValidator); ok {
+		return validator.ValidateAll()
+	}
+	return CallValidatorIfExists(candidate)
+}
+
+// Violation describes a field whose value failed validation.
+// It is returned by the generated validators, and can be retrieved from their errors using errors.As.
+type Violation struct {
+	// FieldPath is the path of Go field names leading to the offending field, starting at the validated message.
+	FieldPath []string
+	// ProtoFieldPath is the path of .proto field names leading to the offending field.
+	ProtoFieldPath []string
+	// Constraint is the name of the violated constraint, as used in the .proto file (e.g. "int_gt", "regex").
+	// It is empty if the violation wasn't caused by a validator constraint.
+	Constraint string
+	// ConstraintValue is the value the violated constraint is configured with.
+	ConstraintValue interface{}
+	// Value is the offending value of the field.
+	Value interface{}
+	// Err describes the violation.
+	Err error
+}
+
+func (v *Violation) Error() string {
+	if len(v.FieldPath) == 0 {
+		return v.Err.Error()
+	}
+	return "invalid field " + strings.Join(v.FieldPath, ".") + ": " + v.Err.Error()
+}
+
+// Unwrap returns the error describing the violation.
+func (v *Violation) Unwrap() error {
+	return v.Err
 }
 
-func (f *fieldError) Error() string {
-	return "invalid field " + strings.Join(f.fieldStack, ".") + ": " + f.nestedErr.Error()
+// ConstraintError returns a Violation of the named constraint by the given value.
+// Use FieldError to add the field path to it.
+func ConstraintError(constraint string, constraintValue interface{}, value interface{}, err error) error {
+	return &Violation{
+		Constraint:      constraint,
+		ConstraintValue: constraintValue,
+		Value:           value,
+		Err:             err,
+	}
 }
 
 // FieldError wraps a given Validator error providing a message call stack.
+// If err is a list of Errors, each of them is wrapped.
 func FieldError(fieldName string, err error) error {
-	if fErr, ok := err.(*fieldError); ok {
-		fErr.fieldStack = append([]string{fieldName}, fErr.fieldStack...)
+	return ProtoFieldError(fieldName, fieldName, err)
+}
+
+// ProtoFieldError is like FieldError, but also records the name of the field in the .proto file.
+func ProtoFieldError(fieldName string, protoFieldName string, err error) error {
+	if errs, ok := err.(Errors); ok {
+		for i, err := range errs {
+			errs[i] = ProtoFieldError(fieldName, protoFieldName, err)
+		}
+		return errs
+	}
+	if violation, ok := err.(*Violation); ok {
+		violation.FieldPath = append([]string{fieldName}, violation.FieldPath...)
+		violation.ProtoFieldPath = append([]string{protoFieldName}, violation.ProtoFieldPath...)
 		return err
 	}
-	return &fieldError{
-		fieldStack: []string{fieldName},
-		nestedErr:  err,
+	return &Violation{
+		FieldPath:      []string{fieldName},
+		ProtoFieldPath: []string{protoFieldName},
+		Err:            err,
+	}
+}
+
+// MapFieldError wraps a given Validator error of a map entry, providing a message call stack including the entry key.
+func MapFieldError(fieldName string, key interface{}, err error) error {
+	return ProtoMapFieldError(fieldName, fieldName, key, err)
+}
+
+// ProtoMapFieldError is like MapFieldError, but also records the name of the field in the .proto file.
+func ProtoMapFieldError(fieldName string, protoFieldName string, key interface{}, err error) error {
+	return ProtoFieldError(fmt.Sprintf("%s[%v]", fieldName, key), fmt.Sprintf("%s[%v]", protoFieldName, key), err)
+}
+
+// ProtoIndexFieldError wraps a given Validator error of an element of a repeated field, providing a message call stack
+// including the element index.
+func ProtoIndexFieldError(fieldName string, protoFieldName string, index int, err error) error {
+	return ProtoFieldError(fmt.Sprintf("%s[%d]", fieldName, index), fmt.Sprintf("%s[%d]", protoFieldName, index), err)
+}
+
+// SortedMapKeys returns the keys of m in increasing order, with false before true, so that generated code checks the
+// entries of maps in a deterministic order.
+func SortedMapKeys[K comparable, V any](m map[K]V) []K {
+	keys := make([]K, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return LessMapKey(keys[i], keys[j])
+	})
+	return keys
+}
+
+// LessMapKey returns whether the map key a sorts before b, which must have the same type. The keys of maps in .proto
+// files are strings, integers or bools.
+func LessMapKey(a, b interface{}) bool {
+	switch a := a.(type) {
+	case string:
+		return a < b.(string)
+	case int32:
+		return a < b.(int32)
+	case int64:
+		return a < b.(int64)
+	case uint32:
+		return a < b.(uint32)
+	case uint64:
+		return a < b.(uint64)
+	case bool:
+		return !a && b.(bool)
+	}
+	return fmt.Sprint(a) < fmt.Sprint(b)
+}
+
+// secondsNanos is implemented by the Go types of google.protobuf.Timestamp and google.protobuf.Duration.
+type secondsNanos interface {
+	GetSeconds() int64
+	GetNanos() int32
+}
+
+// CompareTimestamps returns -1, 0 or 1 depending on whether the timestamp a is before, equal to or after b.
+// It accepts the Timestamp types of both golang/protobuf and gogo/protobuf.
+func CompareTimestamps(a, b secondsNanos) int {
+	return compareSecondsNanos(a, b)
+}
+
+// CompareDurations returns -1, 0 or 1 depending on whether the duration a is shorter than, equal to or longer than b.
+// It accepts the Duration types of both golang/protobuf and gogo/protobuf.
+func CompareDurations(a, b secondsNanos) int {
+	return compareSecondsNanos(a, b)
+}
+
+// AsTime returns the time of a timestamp, in UTC.
+// It accepts the Timestamp types of both golang/protobuf and gogo/protobuf.
+func AsTime(t secondsNanos) time.Time {
+	return time.Unix(t.GetSeconds(), int64(t.GetNanos())).UTC()
+}
+
+// AsDuration returns the time.Duration of a duration, saturating if it's out of range.
+// It accepts the Duration types of both golang/protobuf and gogo/protobuf.
+func AsDuration(d secondsNanos) time.Duration {
+	seconds, nanos := d.GetSeconds(), time.Duration(d.GetNanos())
+	duration := time.Duration(seconds) * time.Second
+	overflow := duration/time.Second != time.Duration(seconds)
+	duration += nanos
+	overflow = overflow || seconds < 0 && nanos < 0 && duration > 0 || seconds > 0 && nanos > 0 && duration < 0
+	switch {
+	case overflow && seconds < 0:
+		return math.MinInt64
+	case overflow:
+		return math.MaxInt64
+	}
+	return duration
+}
+
+// TimeWithin reports whether t is at most d away from the current time.
+func TimeWithin(t time.Time, d time.Duration) bool {
+	since := time.Since(t)
+	return since <= d && since >= -d
+}
+
+func compareSecondsNanos(a, b secondsNanos) int {
+	switch {
+	case a.GetSeconds() < b.GetSeconds():
+		return -1
+	case a.GetSeconds() > b.GetSeconds():
+		return 1
+	case a.GetNanos() < b.GetNanos():
+		return -1
+	case a.GetNanos() > b.GetNanos():
+		return 1
 	}
+	return 0
+}
+
+// LazyRegexp is a regular expression that is compiled when first used, which the generated validators use instead of
+// compiling all their regular expressions when their package is initialized.
+type LazyRegexp struct {
+	expr string
+	once sync.Once
+	re   *regexp.Regexp
+}
+
+// NewLazyRegexp returns a LazyRegexp for the given expression. It panics when first used if the expression is invalid.
+func NewLazyRegexp(expr string) *LazyRegexp {
+	return &LazyRegexp{expr: expr}
+}
+
+// MatchString reports whether the string s contains any match of the regular expression.
+func (r *LazyRegexp) MatchString(s string) bool {
+	r.once.Do(func() {
+		r.re = regexp.MustCompile(r.expr)
+	})
+	return r.re.MatchString(s)
 }
@@ -0,0 +1,72 @@
+// Copyright 2016 Michal Witkowski. All Rights Reserved.
+// See LICENSE for licensing terms.
+
+package plugin
+
+import (
+	"fmt"
+
+	descriptor "github.com/gogo/protobuf/protoc-gen-gogo/descriptor"
+	"github.com/mwitkow/go-proto-validators"
+)
+
+const anyType = ".google.protobuf.Any"
+
+// checkAnyConstraints reports type URL constraints that no message satisfies.
+func (p *plugin) checkAnyConstraints(name string, fv *validator.FieldValidator) {
+	for _, typeURL := range fv.GetAnyIn() {
+		for _, excluded := range fv.GetAnyNotIn() {
+			if typeURL == excluded {
+				p.fail("field %v has type %q in both validator.any_in and validator.any_not_in", name, typeURL)
+			}
+		}
+	}
+}
+
+// generateAnyValidator checks the google.protobuf.Any pointed to by pointer. Non-nullable fields, which can't be nil,
+// are only unpacked if their type URL is set.
+func (p *plugin) generateAnyValidator(field *descriptor.FieldDescriptorProto, pointer string, nullable bool, fieldName string, fv *validator.FieldValidator) {
+	if field.GetTypeName() != anyType || fv == nil {
+		return
+	}
+	variableName := structField(pointer, "TypeUrl")
+	if len(fv.AnyIn) > 0 {
+		p.P(`if !(`, inCondition(variableName, goLiterals(fv.AnyIn)), `) {`)
+		p.In()
+		errorStr := fmt.Sprintf(`be one of the types %q`, fv.AnyIn)
+		p.generateErrorString(variableName, fieldName, "any_in", fv.AnyIn, errorStr, fv)
+		p.Out()
+		p.P(`}`)
+	}
+	if len(fv.AnyNotIn) > 0 {
+		p.P(`if `, inCondition(variableName, goLiterals(fv.AnyNotIn)), ` {`)
+		p.In()
+		errorStr := fmt.Sprintf(`not be one of the types %q`, fv.AnyNotIn)
+		p.generateErrorString(variableName, fieldName, "any_not_in", fv.AnyNotIn, errorStr, fv)
+		p.Out()
+		p.P(`}`)
+	}
+	if fv.GetAnyValidate() {
+		if !nullable {
+			p.P(`if `, variableName, ` != "" {`)
+			p.In()
+		}
+		p.P(`if msg, typeName, err := `, p.validatorPkg.Use(), `.UnpackAny(`, pointer, `); err != nil {`)
+		p.In()
+		errorExpr := p.fmtPkg.Use() + ".Errorf(`message can't be unpacked: %v`, err)"
+		if fv.GetHumanError() != "" {
+			errorExpr = fmt.Sprint(p.fmtPkg.Use(), ".Errorf(`", fv.GetHumanError(), "`)")
+		}
+		p.generateConstraintError(variableName, fieldName, "any_validate", true, errorExpr)
+		p.Out()
+		p.P(`} else if err := `, p.callValidator(), `(msg); err != nil {`)
+		p.In()
+		p.generateErrorReturn(p.fieldErrorExpr(fieldName, p.validatorPkg.Use()+`.ProtoAnyFieldError(typeName, err)`))
+		p.Out()
+		p.P(`}`)
+		if !nullable {
+			p.Out()
+			p.P(`}`)
+		}
+	}
+}
@@ -0,0 +1,125 @@
+// Copyright 2016 Michal Witkowski. All Rights Reserved.
+// See LICENSE for licensing terms.
+
+package plugin
+
+import (
+	"fmt"
+
+	"github.com/gogo/protobuf/proto"
+	descriptor "github.com/gogo/protobuf/protoc-gen-gogo/descriptor"
+	"github.com/mwitkow/go-proto-validators"
+	"github.com/mwitkow/go-proto-validators/cel"
+	protov2 "google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// celExpression is a CEL expression of a message, fieldName being empty for those of the (validator.message) option.
+type celExpression struct {
+	*validator.CelExpression
+	fieldName string
+	varName   string
+}
+
+// generateCELVars compiles the CEL expressions of the message and its fields once, when the package is initialized.
+// This happens in an init function, as the message types are registered after the package variables are initialized.
+// The expressions are type-checked beforehand, so that compiling them can't fail.
+func (p *plugin) generateCELVars(message *goMessage) {
+	var exprs []celExpression
+	for _, field := range message.fields {
+		fv := getFieldValidatorIfAny(field.FieldDescriptorProto)
+		for i, expr := range fv.GetCel() {
+			exprs = append(exprs, celExpression{expr, field.GetName(), p.celName(message.typeName, field.GetName(), i)})
+		}
+	}
+	for i, expr := range message.validator.GetCel() {
+		exprs = append(exprs, celExpression{expr, "", p.celName(message.typeName, "", i)})
+	}
+	if len(exprs) == 0 {
+		return
+	}
+	md := p.messageDescriptor(message)
+	if md == nil {
+		return
+	}
+	for _, expr := range exprs {
+		if _, err := cel.Compile(md, expr.fieldName, expr.GetExpression(), expr.GetMessage()); err != nil {
+			if expr.fieldName == "" {
+				p.fail("message %v has an invalid CEL expression %q: %v", message.typeName, expr.GetExpression(), err)
+			} else {
+				p.fail("field %v.%v has an invalid CEL expression %q: %v", message.typeName, expr.fieldName, expr.GetExpression(), err)
+			}
+		}
+		p.P(`var `, expr.varName, ` *`, p.celPkg.Use(), `.Program`)
+	}
+	p.P()
+	p.P(`func init() {`)
+	p.In()
+	for _, expr := range exprs {
+		p.P(expr.varName, ` = `, p.celPkg.Use(), `.MustCompile((*`, message.typeName, `)(nil), `, goLiteral(expr.fieldName), `, `, goLiteral(expr.GetExpression()), `, `, goLiteral(expr.GetMessage()), `)`)
+	}
+	p.Out()
+	p.P(`}`)
+	p.P()
+}
+
+// generateCELValidator evaluates the CEL expressions of a field, variableName being the value of the field.
+func (p *plugin) generateCELValidator(message *goMessage, field *goField, variableName string, fieldName string, fv *validator.FieldValidator) {
+	for i, expr := range fv.GetCel() {
+		p.P(`if err := `, p.celName(message.typeName, field.GetName(), i), `.Eval(this); err != nil {`)
+		p.In()
+		p.generateConstraintError(variableName, fieldName, "cel", expr.GetExpression(), "err")
+		p.Out()
+		p.P(`}`)
+	}
+}
+
+// generateMessageCELValidator evaluates the CEL expressions of the (validator.message) option.
+func (p *plugin) generateMessageCELValidator(message *goMessage) {
+	for i, expr := range message.validator.GetCel() {
+		p.P(`if err := `, p.celName(message.typeName, "", i), `.Eval(this); err != nil {`)
+		p.In()
+		p.generateErrorReturn(fmt.Sprint(p.validatorPkg.Use(), `.ConstraintError("cel", `, goLiteral(expr.GetExpression()), `, this, err)`))
+		p.Out()
+		p.P(`}`)
+	}
+}
+
+func (p *plugin) celName(ccTypeName string, fieldName string, i int) string {
+	if fieldName == "" {
+		return fmt.Sprintf("_cel_%s_%d", ccTypeName, i)
+	}
+	return fmt.Sprintf("_cel_%s_%s_%d", ccTypeName, fieldName, i)
+}
+
+// messageDescriptor returns the descriptor of the message, against which CEL expressions are checked.
+// With gogo, it's built from the descriptors of the request.
+func (p *plugin) messageDescriptor(message *goMessage) protoreflect.MessageDescriptor {
+	if message.desc != nil {
+		return message.desc
+	}
+	if p.files == nil {
+		b, err := proto.Marshal(&descriptor.FileDescriptorSet{File: p.Request.ProtoFile})
+		if err != nil {
+			p.fail("failed to marshal the descriptors: %v", err)
+			return nil
+		}
+		set := &descriptorpb.FileDescriptorSet{}
+		if err := protov2.Unmarshal(b, set); err != nil {
+			p.fail("failed to unmarshal the descriptors: %v", err)
+			return nil
+		}
+		if p.files, err = protodesc.NewFiles(set); err != nil {
+			p.fail("failed to load the descriptors: %v", err)
+			return nil
+		}
+	}
+	d, err := p.files.FindDescriptorByName(protoreflect.FullName(message.fullName))
+	if err != nil {
+		p.fail("failed to find message %v: %v", message.fullName, err)
+		return nil
+	}
+	return d.(protoreflect.MessageDescriptor)
+}
@@ -0,0 +1,64 @@
+// Copyright 2016 Michal Witkowski. All Rights Reserved.
+// See LICENSE for licensing terms.
+
+package plugin
+
+import (
+	"github.com/gogo/protobuf/proto"
+	descriptor "github.com/gogo/protobuf/protoc-gen-gogo/descriptor"
+	"github.com/mwitkow/go-proto-validators"
+)
+
+func getFileValidatorIfAny(options *descriptor.FileOptions) *validator.FileValidator {
+	if options != nil {
+		v, err := proto.GetExtension(options, validator.E_File)
+		if err == nil && v.(*validator.FileValidator) != nil {
+			return (v.(*validator.FileValidator))
+		}
+	}
+	return nil
+}
+
+// withFileDefaults returns the field with the defaults of the (validator.file) option applied to the constraints of
+// its float values, so that they are generated and checked like constraints of the field itself. The field is copied
+// if a default applies to it.
+func (p *plugin) withFileDefaults(field *descriptor.FieldDescriptorProto, mapEntry *descriptor.DescriptorProto, fileValidator *validator.FileValidator) *descriptor.FieldDescriptorProto {
+	if fileValidator == nil {
+		return field
+	}
+	valueField := field
+	if mapEntry != nil {
+		valueField = mapEntry.Field[1]
+	}
+	if wrapped := wrappedField(valueField); wrapped != nil {
+		valueField = wrapped
+	}
+	if !p.isSupportedFloat(valueField) {
+		return field
+	}
+	fv := &validator.FieldValidator{}
+	if existing := getFieldValidatorIfAny(field); existing != nil {
+		fv = proto.Clone(existing).(*validator.FieldValidator)
+	}
+	target := fv
+	if mapEntry != nil {
+		if fv.MapValue == nil {
+			fv.MapValue = &validator.FieldValidator{}
+		}
+		target = fv.MapValue
+	}
+	if target.FloatFinite == nil {
+		target.FloatFinite = fileValidator.FloatFinite
+	}
+	if target.FloatNotNan == nil {
+		target.FloatNotNan = fileValidator.FloatNotNan
+	}
+	field = proto.Clone(field).(*descriptor.FieldDescriptorProto)
+	if field.Options == nil {
+		field.Options = &descriptor.FieldOptions{}
+	}
+	if err := proto.SetExtension(field.Options, validator.E_Field, fv); err != nil {
+		p.fail("field %v: %v", field.GetName(), err)
+	}
+	return field
+}
@@ -0,0 +1,86 @@
+// Copyright 2016 Michal Witkowski. All Rights Reserved.
+// See LICENSE for licensing terms.
+
+package plugin
+
+import (
+	"fmt"
+	"strconv"
+
+	descriptor "github.com/gogo/protobuf/protoc-gen-gogo/descriptor"
+	"github.com/mwitkow/go-proto-validators"
+)
+
+// checkEnumConstraints reports enum constraints that no value satisfies.
+func (p *plugin) checkEnumConstraints(name string, fv *validator.FieldValidator) {
+	for _, number := range fv.GetEnumIn() {
+		for _, excluded := range fv.GetEnumNotIn() {
+			if number == excluded {
+				p.fail("field %v has value %d in both validator.enum_in and validator.enum_not_in", name, number)
+			}
+		}
+	}
+	if fv.GetEnumNotZero() && len(fv.GetEnumIn()) == 1 && fv.GetEnumIn()[0] == 0 {
+		p.fail("field %v has validator.enum_in 0, which contradicts validator.enum_not_zero", name)
+	}
+}
+
+// generateEnumValidator checks the value of an enum field. Values are printed with the name of the enum value.
+func (p *plugin) generateEnumValidator(enum *descriptor.EnumDescriptorProto, variableName string, fieldName string, fv *validator.FieldValidator) {
+	if enum == nil || fv == nil {
+		return
+	}
+	if fv.GetEnumDefinedOnly() {
+		var defined []int32
+		seen := make(map[int32]bool)
+		for _, value := range enum.GetValue() {
+			if !seen[value.GetNumber()] {
+				seen[value.GetNumber()] = true
+				defined = append(defined, value.GetNumber())
+			}
+		}
+		p.P(`if !(`, inCondition(variableName, goLiterals(defined)), `) {`)
+		p.In()
+		p.generateErrorString(variableName, fieldName, "enum_defined_only", true, "be a defined enum value", fv)
+		p.Out()
+		p.P(`}`)
+	}
+	if len(fv.EnumIn) > 0 {
+		p.P(`if !(`, inCondition(variableName, goLiterals(fv.EnumIn)), `) {`)
+		p.In()
+		errorStr := fmt.Sprintf(`be one of the values %v`, enumValueNames(enum, fv.EnumIn))
+		p.generateErrorString(variableName, fieldName, "enum_in", fv.EnumIn, errorStr, fv)
+		p.Out()
+		p.P(`}`)
+	}
+	if len(fv.EnumNotIn) > 0 {
+		p.P(`if `, inCondition(variableName, goLiterals(fv.EnumNotIn)), ` {`)
+		p.In()
+		errorStr := fmt.Sprintf(`not be one of the values %v`, enumValueNames(enum, fv.EnumNotIn))
+		p.generateErrorString(variableName, fieldName, "enum_not_in", fv.EnumNotIn, errorStr, fv)
+		p.Out()
+		p.P(`}`)
+	}
+	if fv.GetEnumNotZero() {
+		p.P(`if `, variableName, ` == 0 {`)
+		p.In()
+		p.generateErrorString(variableName, fieldName, "enum_not_zero", true, "not be the zero value", fv)
+		p.Out()
+		p.P(`}`)
+	}
+}
+
+// enumValueNames returns the names of the enum values with the given numbers, or the number if there is none.
+func enumValueNames(enum *descriptor.EnumDescriptorProto, numbers []int32) []string {
+	names := make([]string, len(numbers))
+	for i, number := range numbers {
+		names[i] = strconv.Itoa(int(number))
+		for _, value := range enum.GetValue() {
+			if value.GetNumber() == number {
+				names[i] = value.GetName()
+				break
+			}
+		}
+	}
+	return names
+}
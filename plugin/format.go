@@ -0,0 +1,122 @@
+// Copyright 2016 Michal Witkowski. All Rights Reserved.
+// See LICENSE for licensing terms.
+
+package plugin
+
+import (
+	"fmt"
+	"strings"
+
+	descriptor "github.com/gogo/protobuf/protoc-gen-gogo/descriptor"
+	"github.com/mwitkow/go-proto-validators"
+)
+
+// stringFormat is a format of string fields, which is checked by a helper function of the validator package.
+type stringFormat struct {
+	constraint string
+	set        func(fv *validator.FieldValidator) bool
+	// helper is the name of the function of the validator package that checks the format.
+	helper string
+	// description completes the error message, e.g. "value 'x' must be an IP address".
+	description string
+}
+
+var stringFormats = []stringFormat{
+	{"ip", (*validator.FieldValidator).GetIp, "IsIP", "be an IP address"},
+	{"ipv4", (*validator.FieldValidator).GetIpv4, "IsIPv4", "be an IPv4 address"},
+	{"ipv6", (*validator.FieldValidator).GetIpv6, "IsIPv6", "be an IPv6 address"},
+	{"cidr", (*validator.FieldValidator).GetCidr, "IsCIDR", "be an IP network in CIDR notation"},
+	{"hostname", (*validator.FieldValidator).GetHostname, "IsHostname", "be a hostname"},
+	{"host_port", (*validator.FieldValidator).GetHostPort, "IsHostPort", "be a host and port"},
+	{"mac", (*validator.FieldValidator).GetMac, "IsMAC", "be a MAC address"},
+	{"uuid", (*validator.FieldValidator).GetUuid, "IsUUID", "be a UUID"},
+	{"ulid", (*validator.FieldValidator).GetUlid, "IsULID", "be a ULID"},
+	{"hex", (*validator.FieldValidator).GetHex, "IsHex", "be a hex string"},
+	{"email", (*validator.FieldValidator).GetEmail, "IsEmail", "be an email address"},
+	{"uri", (*validator.FieldValidator).GetUri, "IsURI", "be a URI"},
+	{"uri_ref", (*validator.FieldValidator).GetUriRef, "IsURIRef", "be a URI reference"},
+	{"url", (*validator.FieldValidator).GetUrl, "IsURL", "be a URL"},
+	{"utf8", (*validator.FieldValidator).GetUtf8, "IsUTF8", "be valid UTF-8"},
+	{"base64", (*validator.FieldValidator).GetBase64, "IsBase64", "be base64"},
+	{"base64url", (*validator.FieldValidator).GetBase64Url, "IsBase64URL", "be URL-safe base64"},
+	{"json", (*validator.FieldValidator).GetJson, "IsJSON", "be JSON"},
+}
+
+// checkStringFormats reports string formats set on fields that aren't strings, and fields with several formats.
+func (p *plugin) checkStringFormats(name string, field *descriptor.FieldDescriptorProto, fv *validator.FieldValidator) {
+	var set []string
+	for _, format := range stringFormats {
+		if !format.set(fv) {
+			continue
+		}
+		if !field.IsString() {
+			p.fail("field %v has type %v, validator.%v doesn't apply to it", name, field.GetType(), format.constraint)
+		}
+		set = append(set, format.constraint)
+	}
+	if len(set) > 1 {
+		p.fail("field %v can only have one string format, it has validator.%v and validator.%v", name, set[0], set[1])
+	}
+	if fv.UuidVersion != nil {
+		if !fv.GetUuid() {
+			p.fail("field %v has validator.uuid_version without validator.uuid", name)
+		}
+		if fv.GetUuidVersion() < 1 || fv.GetUuidVersion() > 8 {
+			p.fail("field %v has a validator.uuid_version of %d, UUID versions range from 1 to 8", name, fv.GetUuidVersion())
+		}
+	}
+	if fv.HexBytes != nil && field.IsString() && !fv.GetHex() {
+		p.fail("field %v has validator.hex_bytes without validator.hex", name)
+	}
+	if !fv.GetUri() && !fv.GetUriRef() && !fv.GetUrl() {
+		if len(fv.UriSchemes) > 0 {
+			p.fail("field %v has validator.uri_schemes without validator.uri, validator.uri_ref or validator.url", name)
+		}
+		if fv.UriNoUserinfo != nil {
+			p.fail("field %v has validator.uri_no_userinfo without validator.uri, validator.uri_ref or validator.url", name)
+		}
+	}
+}
+
+// generateStringFormatValidator checks the string in variableName against the formats set in fv.
+func (p *plugin) generateStringFormatValidator(variableName string, fieldName string, fv *validator.FieldValidator) {
+	for _, format := range stringFormats {
+		if !format.set(fv) {
+			continue
+		}
+		p.P(`if !`, p.validatorPkg.Use(), `.`, format.helper, `(`, variableName, `) {`)
+		p.In()
+		p.generateErrorString(variableName, fieldName, format.constraint, true, format.description, fv)
+		p.Out()
+		p.P(`}`)
+	}
+	if fv.UuidVersion != nil {
+		p.P(`if !`, p.validatorPkg.Use(), `.IsUUIDVersion(`, variableName, `, `, fmt.Sprint(fv.GetUuidVersion()), `) {`)
+		p.In()
+		errorStr := fmt.Sprintf("be a version %d UUID", fv.GetUuidVersion())
+		p.generateErrorString(variableName, fieldName, "uuid_version", uint64(fv.GetUuidVersion()), errorStr, fv)
+		p.Out()
+		p.P(`}`)
+	}
+	if len(fv.UriSchemes) > 0 {
+		hasScheme := p.validatorPkg.Use() + `.HasURIScheme(` + variableName + `, ` + strings.Join(goLiterals(fv.UriSchemes), `, `) + `)`
+		if fv.GetUriRef() {
+			// Only uri_ref allows relative references, which don't have a scheme.
+			p.P(`if `, p.validatorPkg.Use(), `.IsURI(`, variableName, `) && !`, hasScheme, ` {`)
+		} else {
+			p.P(`if !`, hasScheme, ` {`)
+		}
+		p.In()
+		errorStr := fmt.Sprintf("have one of the schemes %v", fv.UriSchemes)
+		p.generateErrorString(variableName, fieldName, "uri_schemes", fv.UriSchemes, errorStr, fv)
+		p.Out()
+		p.P(`}`)
+	}
+	if fv.GetUriNoUserinfo() {
+		p.P(`if `, p.validatorPkg.Use(), `.HasURIUserinfo(`, variableName, `) {`)
+		p.In()
+		p.generateErrorString(variableName, fieldName, "uri_no_userinfo", true, "not contain userinfo", fv)
+		p.Out()
+		p.P(`}`)
+	}
+}
@@ -0,0 +1,283 @@
+// Copyright 2016 Michal Witkowski. All Rights Reserved.
+// See LICENSE for licensing terms.
+
+package plugin
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/gogo/protobuf/proto"
+	descriptor "github.com/gogo/protobuf/protoc-gen-gogo/descriptor"
+	"github.com/mwitkow/go-proto-validators"
+)
+
+func getMessageValidatorIfAny(message *descriptor.DescriptorProto) *validator.MessageValidator {
+	if message.Options != nil {
+		v, err := proto.GetExtension(message.Options, validator.E_Message)
+		if err == nil && v.(*validator.MessageValidator) != nil {
+			return (v.(*validator.MessageValidator))
+		}
+	}
+	return nil
+}
+
+// comparison is one of the comparisons of a validator.FieldComparison.
+type comparison struct {
+	constraint  string
+	operator    string
+	description string
+	// timeExpr compares two time.Time values using their methods.
+	timeExpr   string
+	otherField string
+}
+
+func comparisons(fc *validator.FieldComparison) []comparison {
+	var cs []comparison
+	for _, c := range []struct {
+		comparison
+		otherField *string
+	}{
+		{comparison{constraint: "lt", operator: "<", description: "less than", timeExpr: "(%s).Before(%s)"}, fc.Lt},
+		{comparison{constraint: "lte", operator: "<=", description: "less than or equal to", timeExpr: "!(%s).After(%s)"}, fc.Lte},
+		{comparison{constraint: "gt", operator: ">", description: "greater than", timeExpr: "(%s).After(%s)"}, fc.Gt},
+		{comparison{constraint: "gte", operator: ">=", description: "greater than or equal to", timeExpr: "!(%s).Before(%s)"}, fc.Gte},
+		{comparison{constraint: "eq", operator: "==", description: "equal to", timeExpr: "(%s).Equal(%s)"}, fc.Eq},
+		{comparison{constraint: "neq", operator: "!=", description: "different from", timeExpr: "!(%s).Equal(%s)"}, fc.Neq},
+	} {
+		if c.otherField != nil {
+			c.comparison.otherField = *c.otherField
+			cs = append(cs, c.comparison)
+		}
+	}
+	return cs
+}
+
+// generateMessageValidator generates the checks of the (validator.message) option, after those of the fields.
+func (p *plugin) generateMessageValidator(message *goMessage) {
+	for _, fc := range message.validator.GetCompare() {
+		field := p.messageField(message, fc.GetField())
+		if field == nil {
+			continue
+		}
+		for _, c := range comparisons(fc) {
+			other := p.messageField(message, c.otherField)
+			if other == nil {
+				continue
+			}
+			p.generateComparison(message, field, other, c, fc.GetHumanError())
+		}
+	}
+	for _, requirement := range message.validator.GetRequiredIf() {
+		field := p.messageField(message, requirement.GetField())
+		ifField := p.messageField(message, requirement.GetIfField())
+		if field == nil || ifField == nil {
+			continue
+		}
+		p.generateRequirement(message, field, ifField, requirement)
+	}
+	p.generateMessageCELValidator(message)
+}
+
+// messageField returns the field referenced by the (validator.message) option using its .proto name.
+func (p *plugin) messageField(message *goMessage, name string) *goField {
+	for _, field := range message.fields {
+		if field.GetName() != name {
+			continue
+		}
+		if field.OneofIndex != nil {
+			p.fail("field %v.%v is part of a oneof, it can't be referenced by the validator.message option", message.typeName, field.goName)
+			return nil
+		}
+		return field
+	}
+	p.fail("message %v has no field %q, referenced by its validator.message option", message.typeName, name)
+	return nil
+}
+
+// generateComparison compares the values of field and other if both are set.
+func (p *plugin) generateComparison(message *goMessage, field *goField, other *goField, c comparison, humanError string) {
+	fieldPresence, fieldValue, ok := p.fieldOperand(message, field)
+	if !ok {
+		return
+	}
+	otherPresence, otherValue, ok := p.fieldOperand(message, other)
+	if !ok {
+		return
+	}
+	if field.GetType() != other.GetType() || field.GetTypeName() != other.GetTypeName() || field.stdtime != other.stdtime || field.stdduration != other.stdduration {
+		p.fail("fields %v.%v and %v.%v have different types and can't be compared", message.typeName, field.goName, message.typeName, other.goName)
+		return
+	}
+	if field.GetType() == descriptor.FieldDescriptorProto_TYPE_BOOL && c.constraint != "eq" && c.constraint != "neq" {
+		p.fail("field %v.%v is a bool, it can only be compared using eq and neq", message.typeName, field.goName)
+		return
+	}
+	var compareExpr string
+	switch {
+	case field.stdtime:
+		// time.Time can't be compared using operators, unlike time.Duration.
+		compareExpr = fmt.Sprintf(c.timeExpr, fieldValue, otherValue)
+	case field.GetTypeName() == ".google.protobuf.Timestamp":
+		compareExpr = fmt.Sprint(p.validatorPkg.Use(), `.CompareTimestamps(`, fieldValue, `, `, otherValue, `) `, c.operator, ` 0`)
+	case field.GetTypeName() == ".google.protobuf.Duration" && !field.stdduration:
+		compareExpr = fmt.Sprint(p.validatorPkg.Use(), `.CompareDurations(`, fieldValue, `, `, otherValue, `) `, c.operator, ` 0`)
+	default:
+		compareExpr = fmt.Sprint(fieldValue, ` `, c.operator, ` `, otherValue)
+	}
+	var conditions []string
+	for _, presence := range []string{fieldPresence, otherPresence} {
+		if presence != "" {
+			conditions = append(conditions, presence)
+		}
+	}
+	conditions = append(conditions, `!(`+compareExpr+`)`)
+	p.P(`if `, strings.Join(conditions, " && "), ` {`)
+	p.In()
+	errorStr := fmt.Sprint(`value must be `, c.description, ` the value of `, other.GetName())
+	if humanError != "" {
+		errorStr = humanError
+	}
+	p.protoFieldName = field.GetName()
+	p.generateConstraintError(fieldValue, field.goName, "compare."+c.constraint, other.GetName(), p.fmtPkg.Use()+".Errorf(`"+errorStr+"`)")
+	p.Out()
+	p.P(`}`)
+}
+
+// generateRequirement requires field to be set if ifField is set or holds the value of the requirement.
+func (p *plugin) generateRequirement(message *goMessage, field *goField, ifField *goField, requirement *validator.FieldRequirement) {
+	isSet, ok := p.fieldSet(message, field)
+	if !ok {
+		return
+	}
+	var condition, errorStr string
+	if requirement.IfEquals == nil {
+		if condition, ok = p.fieldSet(message, ifField); !ok {
+			return
+		}
+		errorStr = fmt.Sprint(`value must be set when `, ifField.GetName(), ` is set`)
+	} else {
+		presence, value, ok := p.fieldOperand(message, ifField)
+		if !ok {
+			return
+		}
+		literal, err := fieldLiteral(ifField, requirement.GetIfEquals())
+		if err != nil {
+			p.fail("field %v.%v can't be compared to %q: %v", message.typeName, ifField.goName, requirement.GetIfEquals(), err)
+			return
+		}
+		condition = fmt.Sprint(value, ` == `, literal)
+		if presence != "" {
+			condition = presence + ` && ` + condition
+		}
+		errorStr = fmt.Sprint(`value must be set when `, ifField.GetName(), ` is '`, requirement.GetIfEquals(), `'`)
+	}
+	if requirement.GetHumanError() != "" {
+		errorStr = requirement.GetHumanError()
+	}
+	p.P(`if `, condition, ` && !(`, isSet, `) {`)
+	p.In()
+	p.protoFieldName = field.GetName()
+	p.generateConstraintError("nil", field.goName, "required_if", ifField.GetName(), p.fmtPkg.Use()+".Errorf(`"+errorStr+"`)")
+	p.Out()
+	p.P(`}`)
+}
+
+// hasPointer returns whether a scalar field is stored in a pointer, which is nil if the field is unset.
+func hasPointer(message *goMessage, field *goField) bool {
+	return field.optional || !message.proto3 && (field.nullable || !message.gogo)
+}
+
+// fieldOperand returns the Go expressions checking whether a single scalar, Timestamp or Duration field is set and
+// holding its value. The presence check is empty if the field is always set.
+func (p *plugin) fieldOperand(message *goMessage, field *goField) (presence string, value string, ok bool) {
+	variableName := "this." + field.goName
+	if field.IsRepeated() || field.IsBytes() {
+		p.fail("field %v.%v is repeated or holds bytes, it can't be compared", message.typeName, field.goName)
+		return "", "", false
+	}
+	if field.IsMessage() {
+		if field.GetTypeName() != ".google.protobuf.Timestamp" && field.GetTypeName() != ".google.protobuf.Duration" {
+			p.fail("field %v.%v is a message, only google.protobuf.Timestamp and google.protobuf.Duration can be compared", message.typeName, field.goName)
+			return "", "", false
+		}
+		stdType := field.stdtime || field.stdduration
+		if field.nullable || !message.gogo {
+			if stdType {
+				return variableName + ` != nil`, `*(` + variableName + `)`, true
+			}
+			return variableName + ` != nil`, variableName, true
+		}
+		if stdType {
+			return "", variableName, true
+		}
+		return "", `&(` + variableName + `)`, true
+	}
+	if hasPointer(message, field) {
+		return variableName + ` != nil`, `*(` + variableName + `)`, true
+	}
+	return "", variableName, true
+}
+
+// fieldSet returns the Go expression checking whether a field is set, i.e. present or holding a non-zero value.
+func (p *plugin) fieldSet(message *goMessage, field *goField) (string, bool) {
+	variableName := "this." + field.goName
+	switch {
+	case field.IsRepeated():
+		return `len(` + variableName + `) > 0`, true
+	case field.IsMessage():
+		if !field.nullable && message.gogo {
+			p.fail("field %v.%v is a nullable=false message, it is always set", message.typeName, field.goName)
+			return "", false
+		}
+		return variableName + ` != nil`, true
+	case hasPointer(message, field):
+		return variableName + ` != nil`, true
+	case field.IsBytes():
+		return `len(` + variableName + `) > 0`, true
+	case field.IsString():
+		return variableName + ` != ""`, true
+	case field.GetType() == descriptor.FieldDescriptorProto_TYPE_BOOL:
+		return variableName, true
+	}
+	return variableName + ` != 0`, true
+}
+
+// fieldLiteral returns the Go literal of the given value of a scalar field, written like in the .proto file.
+func fieldLiteral(field *goField, value string) (string, error) {
+	switch field.GetType() {
+	case descriptor.FieldDescriptorProto_TYPE_ENUM:
+		for _, enumValue := range field.enum.GetValue() {
+			if enumValue.GetName() == value {
+				return strconv.Itoa(int(enumValue.GetNumber())), nil
+			}
+		}
+		n, err := strconv.ParseInt(value, 10, 32)
+		if err != nil {
+			return "", fmt.Errorf("no such enum value")
+		}
+		return strconv.FormatInt(n, 10), nil
+	case descriptor.FieldDescriptorProto_TYPE_STRING:
+		return strconv.Quote(value), nil
+	case descriptor.FieldDescriptorProto_TYPE_BOOL:
+		b, err := strconv.ParseBool(value)
+		return strconv.FormatBool(b), err
+	case descriptor.FieldDescriptorProto_TYPE_FLOAT, descriptor.FieldDescriptorProto_TYPE_DOUBLE:
+		f, err := strconv.ParseFloat(value, 64)
+		return strconv.FormatFloat(f, 'g', -1, 64), err
+	case descriptor.FieldDescriptorProto_TYPE_INT32, descriptor.FieldDescriptorProto_TYPE_SINT32, descriptor.FieldDescriptorProto_TYPE_SFIXED32:
+		n, err := strconv.ParseInt(value, 10, 32)
+		return strconv.FormatInt(n, 10), err
+	case descriptor.FieldDescriptorProto_TYPE_INT64, descriptor.FieldDescriptorProto_TYPE_SINT64, descriptor.FieldDescriptorProto_TYPE_SFIXED64:
+		n, err := strconv.ParseInt(value, 10, 64)
+		return strconv.FormatInt(n, 10), err
+	case descriptor.FieldDescriptorProto_TYPE_UINT32, descriptor.FieldDescriptorProto_TYPE_FIXED32:
+		n, err := strconv.ParseUint(value, 10, 32)
+		return strconv.FormatUint(n, 10), err
+	case descriptor.FieldDescriptorProto_TYPE_UINT64, descriptor.FieldDescriptorProto_TYPE_FIXED64:
+		n, err := strconv.ParseUint(value, 10, 64)
+		return strconv.FormatUint(n, 10), err
+	}
+	return "", fmt.Errorf("only numbers, enums, strings and booleans can be compared to a value")
+}
@@ -50,8 +50,10 @@ package plugin
 
 import (
 	"fmt"
+	"math"
 	"os"
 	"reflect"
+	"regexp"
 	"strconv"
 	"strings"
 
@@ -59,22 +61,128 @@ import (
 	"github.com/gogo/protobuf/proto"
 	descriptor "github.com/gogo/protobuf/protoc-gen-gogo/descriptor"
 	"github.com/gogo/protobuf/protoc-gen-gogo/generator"
-	"github.com/gogo/protobuf/vanity"
 	"github.com/mwitkow/go-proto-validators"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
 )
 
 type plugin struct {
 	*generator.Generator
 	generator.PluginImports
-	regexPkg      generator.Single
-	fmtPkg        generator.Single
+	// out receives the generated code, it's the gogo generator unless generating with protogen.
+	out           printer
+	regexPkg      importedPackage
+	fmtPkg        importedPackage
 	protoPkg      generator.Single
-	validatorPkg  generator.Single
-	useGogoImport bool
+	validatorPkg  importedPackage
+	celPkg        importedPackage
+	timePkg       importedPackage
+	mathPkg       importedPackage
+	utf8Pkg       importedPackage
+	// warningsAsErrors makes warnings about the validator annotations prevent code generation.
+	warningsAsErrors bool
+	// lazyRegex makes the generated regexes compile when first used rather than when their package is initialized.
+	lazyRegex bool
+	// validateAll is set while generating the ValidateAll methods, which collect all errors instead of returning the first.
+	validateAll bool
+	// protoFieldName is the .proto name of the field whose checks are being generated.
+	protoFieldName string
+	// element is set while generating the checks of an element of a repeated or map field, so that errors include its
+	// index or key.
+	element *element
+	// files are the descriptors of the request, used to check CEL expressions when generating with gogo.
+	files *protoregistry.Files
+	// err is the first problem with the validator annotations that prevents code generation, when generating with
+	// protogen. The gogo generator exits instead.
+	err error
 }
 
-func NewPlugin(useGogoImport bool) generator.Plugin {
-	return &plugin{useGogoImport: useGogoImport}
+// printer is the output of the generated code, implemented by the gogo generator.
+type printer interface {
+	P(args ...interface{})
+	In()
+	Out()
+}
+
+// importedPackage is a package used by the generated code. Use returns the name to refer to it by.
+type importedPackage interface {
+	Use() string
+}
+
+// goMessage describes a message and the Go code generated for it, independently of the generator used.
+type goMessage struct {
+	// typeName is the name of the Go type of the message.
+	typeName string
+	// fileName is the path of the .proto file defining the message.
+	fileName string
+	proto3   bool
+	// gogo is set if the Go types honour the gogoproto options, e.g. non-nullable fields.
+	gogo   bool
+	fields []*goField
+	// validator is the (validator.message) option of the message, if any.
+	validator *validator.MessageValidator
+	// fullName is the .proto name of the message, desc its descriptor if available without converting the request.
+	fullName string
+	desc     protoreflect.MessageDescriptor
+	// oneofs are indexed like the OneofIndex of the fields.
+	oneofs []*goOneof
+}
+
+type goOneof struct {
+	// name is the .proto name of the oneof, goName the name of the interface field holding its value.
+	name      string
+	goName    string
+	validator *validator.OneofValidator
+}
+
+// firstOneofMember returns the oneof of the field if it's its first member, or nil.
+func (message *goMessage) firstOneofMember(field *goField) *goOneof {
+	if field.OneofIndex == nil {
+		return nil
+	}
+	for _, f := range message.fields {
+		if f.OneofIndex != nil && *f.OneofIndex == *field.OneofIndex {
+			if f != field {
+				return nil
+			}
+			break
+		}
+	}
+	return message.oneofs[*field.OneofIndex]
+}
+
+type goField struct {
+	*descriptor.FieldDescriptorProto
+	// goName is the name of the field in the Go struct. For oneof members it's the name of the oneof interface field.
+	goName string
+	// oneOfFieldName is the name of oneof members in their wrapper type oneOfTypeName. For other fields it's goName.
+	oneOfFieldName string
+	oneOfTypeName  string
+	nullable       bool
+	// optional is set for proto3 optional fields, which are pointers unless they hold bytes or messages.
+	optional bool
+	// mapEntry is the automatically generated map entry type of map fields.
+	mapEntry *descriptor.DescriptorProto
+	// enum is the type of enum fields, or of the values of maps of enums.
+	enum *descriptor.EnumDescriptorProto
+	// stdtime and stdduration are set if gogo represents the field using time.Time and time.Duration.
+	stdtime     bool
+	stdduration bool
+}
+
+type element struct {
+	fieldName string
+	// indexVariable holds the index of the elements of repeated fields, keyVariable the key of map entries.
+	indexVariable string
+	keyVariable   string
+	// constraintPrefix qualifies the names of violated constraints, e.g. "map_key.".
+	constraintPrefix string
+}
+
+// NewPlugin returns the gogo generator plugin, which generates validators for the Go types generated by
+// protoc-gen-gogo. Validators for the Go types generated by protoc-gen-go are generated by GenerateFile.
+func NewPlugin() generator.Plugin {
+	return &plugin{}
 }
 
 func (p *plugin) Name() string {
@@ -83,29 +191,115 @@ func (p *plugin) Name() string {
 
 func (p *plugin) Init(g *generator.Generator) {
 	p.Generator = g
+	for name, option := range map[string]*bool{"warnings_as_errors": &p.warningsAsErrors, "lazy_regex": &p.lazyRegex} {
+		v, ok := g.Param[name]
+		if !ok {
+			continue
+		}
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			g.Error(err, "parsing "+name+" option")
+		}
+		*option = b
+	}
 }
 
 func (p *plugin) Generate(file *generator.FileDescriptor) {
-	if !p.useGogoImport {
-		vanity.TurnOffGogoImport(file.FileDescriptorProto)
-	}
+	p.out = p.Generator
 	p.PluginImports = generator.NewPluginImports(p.Generator)
 	p.regexPkg = p.NewImport("regexp")
 	p.fmtPkg = p.NewImport("fmt")
 	p.validatorPkg = p.NewImport("github.com/mwitkow/go-proto-validators")
+	p.celPkg = p.NewImport("github.com/mwitkow/go-proto-validators/cel")
+	p.timePkg = p.NewImport("time")
+	p.mathPkg = p.NewImport("math")
+	p.utf8Pkg = p.NewImport("unicode/utf8")
 
 	for _, msg := range file.Messages() {
 		if msg.DescriptorProto.GetOptions().GetMapEntry() {
 			continue
 		}
-		p.generateRegexVars(file, msg)
-		if gogoproto.IsProto3(file.FileDescriptorProto) {
-			p.generateProto3Message(file, msg)
+		p.generateMessage(p.gogoMessage(file, msg))
+	}
+}
+
+// gogoMessage describes a message using the names assigned by the gogo generator.
+func (p *plugin) gogoMessage(file *generator.FileDescriptor, message *generator.Descriptor) *goMessage {
+	msg := &goMessage{
+		typeName:  generator.CamelCaseSlice(message.TypeName()),
+		fileName:  file.GetName(),
+		proto3:    gogoproto.IsProto3(file.FileDescriptorProto),
+		gogo:      gogoproto.ImportsGoGoProto(file.FileDescriptorProto),
+		validator: getMessageValidatorIfAny(message.DescriptorProto),
+		fullName:  strings.Join(message.TypeName(), "."),
+	}
+	if file.GetPackage() != "" {
+		msg.fullName = file.GetPackage() + "." + msg.fullName
+	}
+	for _, oneof := range message.OneofDecl {
+		msg.oneofs = append(msg.oneofs, &goOneof{name: oneof.GetName(), validator: getOneofValidatorIfAny(oneof)})
+	}
+	fileValidator := getFileValidatorIfAny(file.GetOptions())
+	for _, field := range message.Field {
+		f := &goField{
+			FieldDescriptorProto: field,
+			goName:               p.GetFieldName(message, field),
+			oneOfFieldName:       p.GetOneOfFieldName(message, field),
+			nullable:             gogoproto.IsNullable(field),
+			mapEntry:             p.mapEntryMessage(file, message, field),
+			stdtime:              gogoproto.IsStdTime(field),
+			stdduration:          gogoproto.IsStdDuration(field),
+		}
+		if field.OneofIndex != nil {
+			f.oneOfTypeName = p.OneOfTypeName(message, field)
+			msg.oneofs[field.GetOneofIndex()].goName = f.goName
+		}
+		if field.IsEnum() {
+			f.enum = p.ObjectNamed(field.GetTypeName()).(*generator.EnumDescriptor).EnumDescriptorProto
+		} else if f.mapEntry != nil && f.mapEntry.Field[1].IsEnum() {
+			f.enum = p.ObjectNamed(f.mapEntry.Field[1].GetTypeName()).(*generator.EnumDescriptor).EnumDescriptorProto
+		}
+		f.FieldDescriptorProto = p.withFileDefaults(field, f.mapEntry, fileValidator)
+		msg.fields = append(msg.fields, f)
+	}
+	return msg
+}
+
+func (p *plugin) generateMessage(message *goMessage) {
+	p.checkMessage(message)
+	p.generateRegexVars(message)
+	p.generateCELVars(message)
+	for _, validateAll := range []bool{false, true} {
+		p.validateAll = validateAll
+		if message.proto3 {
+			p.generateProto3Message(message)
 		} else {
-			p.generateProto2Message(file, msg)
+			p.generateProto2Message(message)
 		}
+	}
+	p.validateAll = false
+}
+
+func (p *plugin) P(args ...interface{}) {
+	p.out.P(args...)
+}
+
+func (p *plugin) In() {
+	p.out.In()
+}
+
+func (p *plugin) Out() {
+	p.out.Out()
+}
 
+func getOneofValidatorIfAny(oneof *descriptor.OneofDescriptorProto) *validator.OneofValidator {
+	if oneof.Options != nil {
+		v, err := proto.GetExtension(oneof.Options, validator.E_Oneof)
+		if err == nil && v.(*validator.OneofValidator) != nil {
+			return (v.(*validator.OneofValidator))
+		}
 	}
+	return nil
 }
 
 func getFieldValidatorIfAny(field *descriptor.FieldDescriptorProto) *validator.FieldValidator {
@@ -118,6 +312,339 @@ func getFieldValidatorIfAny(field *descriptor.FieldDescriptorProto) *validator.F
 	return nil
 }
 
+// checkMessage reports constraints that can't be satisfied, constraints that don't apply to the type of their field
+// and invalid regexes, before generating any code for the message.
+func (p *plugin) checkMessage(message *goMessage) {
+	for _, field := range message.fields {
+		fv := getFieldValidatorIfAny(field.FieldDescriptorProto)
+		if fv == nil {
+			continue
+		}
+		name := message.typeName + "." + field.goName
+		if field.mapEntry == nil {
+			p.checkFieldValidator(name, field.FieldDescriptorProto, fv)
+			continue
+		}
+		// Other constraints of map fields are reported while generating the map validator.
+		if fv.MapCountMin != nil && fv.MapCountMax != nil && fv.GetMapCountMin() > fv.GetMapCountMax() {
+			p.fail("field %v has a validator.map_count_min greater than its validator.map_count_max", name)
+		}
+		for _, entryField := range field.mapEntry.Field {
+			switch entryField.GetNumber() {
+			case 1:
+				p.checkFieldValidator(name+".key", entryField, fv.MapKey)
+			case 2:
+				p.checkFieldValidator(name+".value", entryField, fv.MapValue)
+			}
+		}
+	}
+}
+
+// checkFieldValidator checks the constraints of a field, or of the keys or values of a map field.
+func (p *plugin) checkFieldValidator(name string, field *descriptor.FieldDescriptorProto, fv *validator.FieldValidator) {
+	if fv == nil {
+		return
+	}
+	message := field.IsMessage()
+	if wrapped := wrappedField(field); wrapped != nil {
+		// The scalar constraints apply to the value of wrapper messages.
+		field = wrapped
+	}
+	// Constraints are applied to each element of repeated fields.
+	for _, c := range []struct {
+		constraint string
+		set        bool
+		applies    bool
+	}{
+		{"regex", fv.Regex != nil, field.IsString()},
+		{"string_not_empty", fv.StringNotEmpty != nil, field.IsString()},
+		{"int_gt", fv.IntGt != nil, p.isSupportedInt(field)},
+		{"int_lt", fv.IntLt != nil, p.isSupportedInt(field)},
+		{"int_gte", fv.IntGte != nil, p.isSupportedInt(field)},
+		{"int_lte", fv.IntLte != nil, p.isSupportedInt(field)},
+		{"int_in", len(fv.IntIn) > 0, p.isSupportedInt(field)},
+		{"int_not_in", len(fv.IntNotIn) > 0, p.isSupportedInt(field)},
+		{"int_const", fv.IntConst != nil, p.isSupportedInt(field)},
+		{"uint_gt", fv.UintGt != nil, p.isSupportedUint(field)},
+		{"uint_lt", fv.UintLt != nil, p.isSupportedUint(field)},
+		{"uint_gte", fv.UintGte != nil, p.isSupportedUint(field)},
+		{"uint_lte", fv.UintLte != nil, p.isSupportedUint(field)},
+		{"float_gt", fv.FloatGt != nil, p.isSupportedFloat(field)},
+		{"float_lt", fv.FloatLt != nil, p.isSupportedFloat(field)},
+		{"float_gte", fv.FloatGte != nil, p.isSupportedFloat(field)},
+		{"float_lte", fv.FloatLte != nil, p.isSupportedFloat(field)},
+		{"float_epsilon", fv.FloatEpsilon != nil, p.isSupportedFloat(field)},
+		{"float_finite", fv.FloatFinite != nil, p.isSupportedFloat(field)},
+		{"float_not_nan", fv.FloatNotNan != nil, p.isSupportedFloat(field)},
+		{"length_gt", fv.LengthGt != nil, field.IsString() || field.IsBytes()},
+		{"length_lt", fv.LengthLt != nil, field.IsString() || field.IsBytes()},
+		{"length_eq", fv.LengthEq != nil, field.IsString() || field.IsBytes()},
+		{"rune_length_gt", fv.RuneLengthGt != nil, field.IsString()},
+		{"rune_length_lt", fv.RuneLengthLt != nil, field.IsString()},
+		{"rune_length_eq", fv.RuneLengthEq != nil, field.IsString()},
+		{"min_runes", fv.MinRunes != nil, field.IsString()},
+		{"max_runes", fv.MaxRunes != nil, field.IsString()},
+		{"hex_bytes", fv.HexBytes != nil, field.IsString() || field.IsBytes()},
+		{"uuid_version", fv.UuidVersion != nil, field.IsString()},
+		{"uri_schemes", len(fv.UriSchemes) > 0, field.IsString()},
+		{"uri_no_userinfo", fv.UriNoUserinfo != nil, field.IsString()},
+		{"bytes_message", fv.BytesMessage != nil, field.IsBytes()},
+		{"msg_exists", fv.MsgExists != nil, message},
+		{"timestamp_lt_now", fv.TimestampLtNow != nil, field.GetTypeName() == timestampType},
+		{"timestamp_gt_now", fv.TimestampGtNow != nil, field.GetTypeName() == timestampType},
+		{"timestamp_within", fv.TimestampWithin != nil, field.GetTypeName() == timestampType},
+		{"timestamp_gt", fv.TimestampGt != nil, field.GetTypeName() == timestampType},
+		{"timestamp_lt", fv.TimestampLt != nil, field.GetTypeName() == timestampType},
+		{"duration_gt", fv.DurationGt != nil, field.GetTypeName() == durationType},
+		{"duration_lt", fv.DurationLt != nil, field.GetTypeName() == durationType},
+		{"any_in", len(fv.AnyIn) > 0, field.GetTypeName() == anyType},
+		{"any_not_in", len(fv.AnyNotIn) > 0, field.GetTypeName() == anyType},
+		{"any_validate", fv.AnyValidate != nil, field.GetTypeName() == anyType},
+		{"enum_defined_only", fv.EnumDefinedOnly != nil, field.IsEnum()},
+		{"enum_in", len(fv.EnumIn) > 0, field.IsEnum()},
+		{"enum_not_in", len(fv.EnumNotIn) > 0, field.IsEnum()},
+		{"enum_not_zero", fv.EnumNotZero != nil, field.IsEnum()},
+	} {
+		if c.set && !c.applies {
+			p.fail("field %v has type %v, validator.%v doesn't apply to it", name, field.GetType(), c.constraint)
+		}
+	}
+	if lower, upper, ok := intBounds(fv); !ok {
+		p.fail("field %v has validator.int_* bounds that no value satisfies", name)
+	} else if fv.IntConst != nil && (fv.GetIntConst() < lower || fv.GetIntConst() > upper) {
+		p.fail("field %v has a validator.int_const outside of its validator.int_* bounds", name)
+	}
+	if _, _, ok := uintBounds(fv); !ok {
+		p.fail("field %v has validator.uint_* bounds that no value satisfies", name)
+	}
+	if p.isSupportedInt(field) {
+		p.checkIntOverflow(name, field, fv)
+	}
+	for _, value := range fv.GetIntNotIn() {
+		if fv.IntConst != nil && value == fv.GetIntConst() {
+			p.fail("field %v has its validator.int_const in validator.int_not_in", name)
+		}
+		for _, allowed := range fv.GetIntIn() {
+			if value == allowed {
+				p.fail("field %v has value %d in both validator.int_in and validator.int_not_in", name, value)
+			}
+		}
+	}
+	for _, c := range []struct {
+		constraint string
+		value      *float64
+	}{
+		{"float_gt", fv.FloatGt},
+		{"float_lt", fv.FloatLt},
+		{"float_gte", fv.FloatGte},
+		{"float_lte", fv.FloatLte},
+	} {
+		if c.value != nil && math.IsNaN(*c.value) {
+			p.fail("field %v has a validator.%v of NaN, which no value satisfies", name, c.constraint)
+		}
+	}
+	if fv.FloatEpsilon != nil && (math.IsNaN(fv.GetFloatEpsilon()) || math.IsInf(fv.GetFloatEpsilon(), 0)) {
+		p.fail("field %v has a validator.float_epsilon that isn't finite", name)
+	}
+	lower, lowerStrict, hasLower := floatLowerBound(fv)
+	upper, upperStrict, hasUpper := floatUpperBound(fv)
+	switch {
+	case hasLower && lowerStrict && math.IsInf(lower, 1), hasUpper && upperStrict && math.IsInf(upper, -1):
+		p.fail("field %v has validator.float_* bounds that no value satisfies", name)
+	case hasLower && hasUpper && (lower > upper || lower == upper && (lowerStrict || upperStrict)):
+		p.fail("field %v has validator.float_* bounds that no value satisfies", name)
+	}
+	if fv.LengthLt != nil && fv.GetLengthLt() <= 0 {
+		p.fail("field %v has a validator.length_lt that no length satisfies", name)
+	}
+	if fv.LengthGt != nil && fv.LengthLt != nil && fv.GetLengthGt()+1 >= fv.GetLengthLt() {
+		p.fail("field %v has no length both greater than validator.length_gt and less than validator.length_lt", name)
+	}
+	if fv.LengthEq != nil {
+		switch {
+		case fv.GetLengthEq() < 0:
+			p.fail("field %v has a negative validator.length_eq", name)
+		case fv.LengthGt != nil && fv.GetLengthEq() <= fv.GetLengthGt():
+			p.fail("field %v has a validator.length_eq that is not greater than its validator.length_gt", name)
+		case fv.LengthLt != nil && fv.GetLengthEq() >= fv.GetLengthLt():
+			p.fail("field %v has a validator.length_eq that is not less than its validator.length_lt", name)
+		case fv.GetStringNotEmpty() && fv.GetLengthEq() == 0:
+			p.fail("field %v has a validator.length_eq of 0, which contradicts validator.string_not_empty", name)
+		}
+	}
+	if fv.GetStringNotEmpty() && fv.LengthLt != nil && fv.GetLengthLt() == 1 {
+		p.fail("field %v has a validator.length_lt of 1, which contradicts validator.string_not_empty", name)
+	}
+	if lower, upper := runeLengthBounds(fv); lower > upper {
+		p.fail("field %v has validator.rune_length_* and validator.*_runes bounds that no length satisfies", name)
+	} else if fv.GetStringNotEmpty() && upper == 0 {
+		p.fail("field %v has a rune length of at most 0, which contradicts validator.string_not_empty", name)
+	} else if maxBytes, ok := byteLengthUpperBound(fv); ok && maxBytes < lower {
+		// Every rune takes at least one byte.
+		p.fail("field %v requires at least %d runes, but validator.length_* allows at most %d bytes", name, lower, maxBytes)
+	}
+	if fv.RepeatedCountMin != nil && fv.RepeatedCountMax != nil && fv.GetRepeatedCountMin() > fv.GetRepeatedCountMax() {
+		p.fail("field %v has a validator.repeated_count_min greater than its validator.repeated_count_max", name)
+	}
+	p.checkTimeConstraints(name, fv)
+	p.checkAnyConstraints(name, fv)
+	p.checkEnumConstraints(name, fv)
+	p.checkStringFormats(name, field, fv)
+}
+
+// runeLengthBounds returns the inclusive range of rune counts that generateRuneLengthValidator allows.
+func runeLengthBounds(fv *validator.FieldValidator) (lower int64, upper int64) {
+	lower, upper = 0, math.MaxInt64
+	if fv.GetRuneLengthGt() == math.MaxInt64 || fv.RuneLengthLt != nil && fv.GetRuneLengthLt() <= 0 {
+		return 1, 0
+	}
+	if fv.RuneLengthGt != nil && fv.GetRuneLengthGt()+1 > lower {
+		lower = fv.GetRuneLengthGt() + 1
+	}
+	if fv.MinRunes != nil && fv.GetMinRunes() > lower {
+		lower = fv.GetMinRunes()
+	}
+	if fv.RuneLengthLt != nil && fv.GetRuneLengthLt()-1 < upper {
+		upper = fv.GetRuneLengthLt() - 1
+	}
+	if fv.MaxRunes != nil && fv.GetMaxRunes() < upper {
+		upper = fv.GetMaxRunes()
+	}
+	if fv.RuneLengthEq != nil {
+		if fv.GetRuneLengthEq() < lower || fv.GetRuneLengthEq() > upper {
+			return 1, 0
+		}
+		lower, upper = fv.GetRuneLengthEq(), fv.GetRuneLengthEq()
+	}
+	return lower, upper
+}
+
+// byteLengthUpperBound returns the largest length in bytes that generateLengthValidator allows, if any.
+func byteLengthUpperBound(fv *validator.FieldValidator) (upper int64, ok bool) {
+	upper = math.MaxInt64
+	if fv.LengthLt != nil {
+		upper, ok = fv.GetLengthLt()-1, true
+	}
+	if fv.LengthEq != nil && fv.GetLengthEq() < upper {
+		upper, ok = fv.GetLengthEq(), true
+	}
+	return upper, ok
+}
+
+// intBounds returns the inclusive range of values that generateIntValidator allows, ok is false if it's empty.
+func intBounds(fv *validator.FieldValidator) (lower int64, upper int64, ok bool) {
+	lower, upper = math.MinInt64, math.MaxInt64
+	if fv.IntGt != nil {
+		if fv.GetIntGt() == math.MaxInt64 {
+			return lower, upper, false
+		}
+		lower = fv.GetIntGt() + 1
+	}
+	if fv.IntGte != nil && fv.GetIntGte() > lower {
+		lower = fv.GetIntGte()
+	}
+	if fv.IntLt != nil {
+		if fv.GetIntLt() == math.MinInt64 {
+			return lower, upper, false
+		}
+		upper = fv.GetIntLt() - 1
+	}
+	if fv.IntLte != nil && fv.GetIntLte() < upper {
+		upper = fv.GetIntLte()
+	}
+	return lower, upper, lower <= upper
+}
+
+// uintBounds returns the inclusive range of values that the uint_* constraints allow, ok is false if it's empty.
+func uintBounds(fv *validator.FieldValidator) (lower uint64, upper uint64, ok bool) {
+	lower, upper = 0, math.MaxUint64
+	if fv.UintGt != nil {
+		if fv.GetUintGt() == math.MaxUint64 {
+			return lower, upper, false
+		}
+		lower = fv.GetUintGt() + 1
+	}
+	if fv.UintGte != nil && fv.GetUintGte() > lower {
+		lower = fv.GetUintGte()
+	}
+	if fv.UintLt != nil {
+		if fv.GetUintLt() == 0 {
+			return lower, upper, false
+		}
+		upper = fv.GetUintLt() - 1
+	}
+	if fv.UintLte != nil && fv.GetUintLte() < upper {
+		upper = fv.GetUintLte()
+	}
+	return lower, upper, lower <= upper
+}
+
+// checkIntOverflow reports integer constraints that don't fit in the Go type of the field, which wouldn't compile.
+func (p *plugin) checkIntOverflow(name string, field *descriptor.FieldDescriptorProto, fv *validator.FieldValidator) {
+	min, max := intTypeRange(field)
+	for _, c := range []struct {
+		constraint string
+		values     []int64
+	}{
+		{"int_gt", optionalInt(fv.IntGt)},
+		{"int_lt", optionalInt(fv.IntLt)},
+		{"int_gte", optionalInt(fv.IntGte)},
+		{"int_lte", optionalInt(fv.IntLte)},
+		{"int_in", fv.IntIn},
+		{"int_not_in", fv.IntNotIn},
+		{"int_const", optionalInt(fv.IntConst)},
+	} {
+		for _, value := range c.values {
+			if value < min || value > 0 && uint64(value) > max {
+				p.fail("field %v has type %v, its validator.%v of %d overflows it", name, field.GetType(), c.constraint, value)
+			}
+		}
+	}
+	for _, c := range []struct {
+		constraint string
+		value      *uint64
+	}{
+		{"uint_gt", fv.UintGt},
+		{"uint_lt", fv.UintLt},
+		{"uint_gte", fv.UintGte},
+		{"uint_lte", fv.UintLte},
+	} {
+		if c.value != nil && *c.value > max {
+			p.fail("field %v has type %v, its validator.%v of %d overflows it", name, field.GetType(), c.constraint, *c.value)
+		}
+	}
+}
+
+// optionalInt returns the value of an optional integer constraint as a slice, which is empty if it isn't set.
+func optionalInt(value *int64) []int64 {
+	if value == nil {
+		return nil
+	}
+	return []int64{*value}
+}
+
+// floatLowerBound returns the lower bound that generateFloatValidator checks, if any.
+func floatLowerBound(fv *validator.FieldValidator) (bound float64, strict bool, ok bool) {
+	if fv.FloatGt != nil {
+		bound, strict, ok = fv.GetFloatGt()-fv.GetFloatEpsilon(), true, true
+	}
+	if fv.FloatGte != nil && (!ok || fv.GetFloatGte() > bound) {
+		bound, strict, ok = fv.GetFloatGte(), false, true
+	}
+	return bound, strict, ok
+}
+
+// floatUpperBound returns the upper bound that generateFloatValidator checks, if any.
+func floatUpperBound(fv *validator.FieldValidator) (bound float64, strict bool, ok bool) {
+	if fv.FloatLt != nil {
+		bound, strict, ok = fv.GetFloatLt()+fv.GetFloatEpsilon(), true, true
+	}
+	if fv.FloatLte != nil && (!ok || fv.GetFloatLte() < bound) {
+		bound, strict, ok = fv.GetFloatLte(), false, true
+	}
+	return bound, strict, ok
+}
+
 func (p *plugin) isSupportedInt(field *descriptor.FieldDescriptorProto) bool {
 	switch *(field.Type) {
 	case descriptor.FieldDescriptorProto_TYPE_INT32, descriptor.FieldDescriptorProto_TYPE_INT64:
@@ -126,60 +653,118 @@ func (p *plugin) isSupportedInt(field *descriptor.FieldDescriptorProto) bool {
 		return true
 	case descriptor.FieldDescriptorProto_TYPE_SINT32, descriptor.FieldDescriptorProto_TYPE_SINT64:
 		return true
+	case descriptor.FieldDescriptorProto_TYPE_FIXED32, descriptor.FieldDescriptorProto_TYPE_FIXED64:
+		return true
+	case descriptor.FieldDescriptorProto_TYPE_SFIXED32, descriptor.FieldDescriptorProto_TYPE_SFIXED64:
+		return true
 	}
 	return false
 }
 
-func (p *plugin) isSupportedFloat(field *descriptor.FieldDescriptorProto) bool {
+func (p *plugin) isSupportedUint(field *descriptor.FieldDescriptorProto) bool {
 	switch *(field.Type) {
-	case descriptor.FieldDescriptorProto_TYPE_FLOAT, descriptor.FieldDescriptorProto_TYPE_DOUBLE:
+	case descriptor.FieldDescriptorProto_TYPE_UINT32, descriptor.FieldDescriptorProto_TYPE_UINT64:
 		return true
 	case descriptor.FieldDescriptorProto_TYPE_FIXED32, descriptor.FieldDescriptorProto_TYPE_FIXED64:
 		return true
-	case descriptor.FieldDescriptorProto_TYPE_SFIXED32, descriptor.FieldDescriptorProto_TYPE_SFIXED64:
+	}
+	return false
+}
+
+// intTypeRange returns the range of the Go type of an integer field, which its constraints must not overflow.
+func intTypeRange(field *descriptor.FieldDescriptorProto) (min int64, max uint64) {
+	switch *(field.Type) {
+	case descriptor.FieldDescriptorProto_TYPE_INT32, descriptor.FieldDescriptorProto_TYPE_SINT32, descriptor.FieldDescriptorProto_TYPE_SFIXED32:
+		return math.MinInt32, math.MaxInt32
+	case descriptor.FieldDescriptorProto_TYPE_UINT32, descriptor.FieldDescriptorProto_TYPE_FIXED32:
+		return 0, math.MaxUint32
+	case descriptor.FieldDescriptorProto_TYPE_UINT64, descriptor.FieldDescriptorProto_TYPE_FIXED64:
+		return 0, math.MaxUint64
+	}
+	return math.MinInt64, math.MaxInt64
+}
+
+func (p *plugin) isSupportedFloat(field *descriptor.FieldDescriptorProto) bool {
+	switch *(field.Type) {
+	case descriptor.FieldDescriptorProto_TYPE_FLOAT, descriptor.FieldDescriptorProto_TYPE_DOUBLE:
 		return true
 	}
 	return false
 }
 
-func (p *plugin) generateRegexVars(file *generator.FileDescriptor, message *generator.Descriptor) {
-	ccTypeName := generator.CamelCaseSlice(message.TypeName())
-	for _, field := range message.Field {
-		validator := getFieldValidatorIfAny(field)
-		if validator != nil && validator.Regex != nil {
-			fieldName := p.GetFieldName(message, field)
-			p.P(`var `, p.regexName(ccTypeName, fieldName), ` = `, p.regexPkg.Use(), `.MustCompile(`, "`", *validator.Regex, "`", `)`)
+func (p *plugin) generateRegexVars(message *goMessage) {
+	ccTypeName := message.typeName
+	for _, field := range message.fields {
+		validator := getFieldValidatorIfAny(field.FieldDescriptorProto)
+		if validator == nil {
+			continue
+		}
+		// Named like the checks, after the oneof member rather than the oneof.
+		fieldName := field.oneOfFieldName
+		if validator.Regex != nil {
+			p.generateRegexVar(message, field.GetName(), p.regexName(ccTypeName, fieldName), validator.GetRegex())
+		}
+		if validator.MapKey != nil && validator.MapKey.Regex != nil {
+			p.generateRegexVar(message, field.GetName()+" key", p.regexName(ccTypeName+"_"+fieldName, "key"), validator.MapKey.GetRegex())
+		}
+		if validator.MapValue != nil && validator.MapValue.Regex != nil {
+			p.generateRegexVar(message, field.GetName()+" value", p.regexName(ccTypeName+"_"+fieldName, "value"), validator.MapValue.GetRegex())
 		}
 	}
 }
 
-func (p *plugin) generateProto2Message(file *generator.FileDescriptor, message *generator.Descriptor) {
-	ccTypeName := generator.CamelCaseSlice(message.TypeName())
+// generateRegexVar declares the variable holding a regex, which is compiled here first so that invalid regexes fail the
+// generation instead of panicking when the generated package is initialized.
+func (p *plugin) generateRegexVar(message *goMessage, fieldName string, varName string, regex string) {
+	if _, err := regexp.Compile(regex); err != nil {
+		p.fail("%v: message %v, field %v: invalid validator.regex: %v", message.fileName, message.fullName, fieldName, err)
+		return
+	}
+	if p.lazyRegex {
+		p.P(`var `, varName, ` = `, p.validatorPkg.Use(), `.NewLazyRegexp(`, goLiteral(regex), `)`)
+	} else {
+		p.P(`var `, varName, ` = `, p.regexPkg.Use(), `.MustCompile(`, goLiteral(regex), `)`)
+	}
+}
 
-	p.P(`func (this *`, ccTypeName, `) Validate() error {`)
-	p.In()
-	for _, field := range message.Field {
-		fieldName := p.GetFieldName(message, field)
-		fieldValidator := getFieldValidatorIfAny(field)
+func (p *plugin) generateProto2Message(message *goMessage) {
+	ccTypeName := message.typeName
+
+	p.generateValidateFuncStart(ccTypeName)
+	for _, field := range message.fields {
+		if oneof := message.firstOneofMember(field); oneof != nil {
+			p.generateOneofValidator(oneof)
+		}
+		fieldName := field.goName
+		fieldValidator := getFieldValidatorIfAny(field.FieldDescriptorProto)
 		if fieldValidator == nil && !field.IsMessage() {
 			continue
 		}
+		p.protoFieldName = field.GetName()
 		if p.validatorWithMessageExists(fieldValidator) {
-			fmt.Fprintf(os.Stderr, "WARNING: field %v.%v is a proto2 message, validator.msg_exists has no effect\n", ccTypeName, fieldName)
+			p.warning("field %v.%v is a proto2 message, validator.msg_exists has no effect", ccTypeName, fieldName)
 		}
 		variableName := "this." + fieldName
+		if field.mapEntry != nil {
+			p.generateMapValidator(message, variableName, ccTypeName, fieldName, field, fieldValidator)
+			continue
+		}
+		p.warnIfMapConstraint(ccTypeName, fieldName, fieldValidator)
 		repeated := field.IsRepeated()
-		nullable := gogoproto.IsNullable(field)
+		nullable := field.nullable
 		// For proto2 syntax, only Gogo generates non-pointer fields
-		nonpointer := gogoproto.ImportsGoGoProto(file.FileDescriptorProto) && !gogoproto.IsNullable(field)
+		nonpointer := message.gogo && !field.nullable
 		if repeated {
 			p.generateRepeatedCountValidator(variableName, ccTypeName, fieldName, fieldValidator)
+			p.generateCELValidator(message, field, variableName, fieldName, fieldValidator)
 			if field.IsMessage() || p.validatorWithNonRepeatedConstraint(fieldValidator) {
-				p.P(`for _, item := range `, variableName, `{`)
+				p.P(`for i, item := range `, variableName, `{`)
 				p.In()
+				p.element = &element{fieldName: fieldName, indexVariable: "i"}
 				variableName = "item"
 			}
 		} else if nullable {
+			p.generateRequiredValidator(variableName, fieldName, fieldValidator)
 			p.P(`if `, variableName, ` != nil {`)
 			p.In()
 			if !field.IsBytes() {
@@ -190,29 +775,37 @@ func (p *plugin) generateProto2Message(file *generator.FileDescriptor, message *
 		} else if !field.IsMessage() {
 			variableName = `this.Get` + fieldName + `()`
 		}
+		if (repeated || !nullable) && fieldValidator.GetRequired() {
+			p.warning("field %v.%v has no presence, validator.required has no effect", ccTypeName, fieldName)
+		}
 		if !repeated && fieldValidator != nil {
 			if fieldValidator.RepeatedCountMin != nil {
-				fmt.Fprintf(os.Stderr, "WARNING: field %v.%v is not repeated, validator.min_elts has no effects\n", ccTypeName, fieldName)
+				p.warning("field %v.%v is not repeated, validator.min_elts has no effects", ccTypeName, fieldName)
 			}
 			if fieldValidator.RepeatedCountMax != nil {
-				fmt.Fprintf(os.Stderr, "WARNING: field %v.%v is not repeated, validator.max_elts has no effects\n", ccTypeName, fieldName)
+				p.warning("field %v.%v is not repeated, validator.max_elts has no effects", ccTypeName, fieldName)
+			}
+			if field.IsMessage() && nullable {
+				// Avoid copying the message.
+				p.generateCELValidator(message, field, "this."+fieldName, fieldName, fieldValidator)
+			} else {
+				p.generateCELValidator(message, field, variableName, fieldName, fieldValidator)
 			}
 		}
-		if field.IsString() {
-			p.generateStringValidator(variableName, ccTypeName, fieldName, fieldValidator)
-		} else if p.isSupportedInt(field) {
-			p.generateIntValidator(variableName, ccTypeName, fieldName, fieldValidator)
-		} else if p.isSupportedFloat(field) {
-			p.generateFloatValidator(variableName, ccTypeName, fieldName, fieldValidator)
-		} else if field.IsBytes() {
-			p.generateLengthValidator(variableName, ccTypeName, fieldName, fieldValidator)
+		if p.isSupportedScalar(field.FieldDescriptorProto) {
+			p.generateScalarValidator(field.FieldDescriptorProto, variableName, ccTypeName, fieldName, fieldValidator)
+		} else if field.IsEnum() {
+			p.generateEnumValidator(field.enum, variableName, fieldName, fieldValidator)
 		} else if field.IsMessage() {
 			if repeated && nullable {
 				variableName = "*(item)"
 			}
-			p.P(`if err := `, p.validatorPkg.Use(), `.CallValidatorIfExists(&(`, variableName, `)); err != nil {`)
+			p.generateTimeValidator(field.FieldDescriptorProto, field.stdtime || field.stdduration, "&("+variableName+")", fieldName, fieldValidator)
+			p.generateWrapperValidator(field.FieldDescriptorProto, "&("+variableName+")", ccTypeName, fieldName, fieldValidator)
+			p.generateAnyValidator(field.FieldDescriptorProto, "&("+variableName+")", nullable, fieldName, fieldValidator)
+			p.P(`if err := `, p.callValidator(), `(&(`, variableName, `)); err != nil {`)
 			p.In()
-			p.P(`return `, p.validatorPkg.Use(), `.FieldError("`, fieldName, `", err)`)
+			p.generateErrorReturn(p.fieldErrorExpr(fieldName, "err"))
 			p.Out()
 			p.P(`}`)
 		}
@@ -220,6 +813,7 @@ func (p *plugin) generateProto2Message(file *generator.FileDescriptor, message *
 			// end the repeated loop
 			if field.IsMessage() || p.validatorWithNonRepeatedConstraint(fieldValidator) {
 				// This internal 'if' cannot be refactored as it would change semantics with respect to the corresponding prelude 'if's
+				p.element = nil
 				p.Out()
 				p.P(`}`)
 			}
@@ -229,73 +823,93 @@ func (p *plugin) generateProto2Message(file *generator.FileDescriptor, message *
 			p.P(`}`)
 		}
 	}
-	p.P(`return nil`)
-	p.Out()
-	p.P(`}`)
+	p.generateMessageValidator(message)
+	p.generateValidateFuncEnd()
 }
 
-func (p *plugin) generateProto3Message(file *generator.FileDescriptor, message *generator.Descriptor) {
-	ccTypeName := generator.CamelCaseSlice(message.TypeName())
-	p.P(`func (this *`, ccTypeName, `) Validate() error {`)
-	p.In()
-	for _, field := range message.Field {
-		fieldValidator := getFieldValidatorIfAny(field)
+func (p *plugin) generateProto3Message(message *goMessage) {
+	ccTypeName := message.typeName
+	p.generateValidateFuncStart(ccTypeName)
+	for _, field := range message.fields {
+		if oneof := message.firstOneofMember(field); oneof != nil {
+			p.generateOneofValidator(oneof)
+		}
+		fieldValidator := getFieldValidatorIfAny(field.FieldDescriptorProto)
 		if fieldValidator == nil && !field.IsMessage() {
 			continue
 		}
+		p.protoFieldName = field.GetName()
 		isOneOf := field.OneofIndex != nil
-		fieldName := p.GetOneOfFieldName(message, field)
+		fieldName := field.oneOfFieldName
 		variableName := "this." + fieldName
 		repeated := field.IsRepeated()
 		// Golang's proto3 has no concept of unset primitive fields
-		nullable := (gogoproto.IsNullable(field) || !gogoproto.ImportsGoGoProto(file.FileDescriptorProto)) && field.IsMessage()
-		if p.fieldIsProto3Map(file, message, field) {
-			p.P(`// Validation of proto3 map<> fields is unsupported.`)
+		nullable := (field.nullable || !message.gogo) && field.IsMessage()
+		if field.mapEntry != nil {
+			p.generateMapValidator(message, variableName, ccTypeName, fieldName, field, fieldValidator)
 			continue
 		}
+		p.warnIfMapConstraint(ccTypeName, fieldName, fieldValidator)
 		if isOneOf {
 			p.In()
-			oneOfName := p.GetFieldName(message, field)
-			oneOfType := p.OneOfTypeName(message, field)
+			oneOfName := field.goName
+			oneOfType := field.oneOfTypeName
 			//if x, ok := m.GetType().(*OneOfMessage3_OneInt); ok {
 			p.P(`if oneOfNester, ok := this.Get` + oneOfName + `().(* ` + oneOfType + `); ok {`)
-			variableName = "oneOfNester." + p.GetOneOfFieldName(message, field)
+			variableName = "oneOfNester." + field.oneOfFieldName
+		}
+		// proto3 optional scalars are pointers, their constraints only apply if they are set.
+		presence := field.optional && !field.IsMessage()
+		if presence {
+			p.generateRequiredValidator(variableName, fieldName, fieldValidator)
+			p.P(`if `, variableName, ` != nil {`)
+			p.In()
+			if !field.IsBytes() {
+				variableName = "*(" + variableName + ")"
+			}
+		} else if fieldValidator.GetRequired() {
+			if field.IsMessage() && !repeated {
+				p.warning("field %v.%v is a message, use validator.msg_exists instead of validator.required", ccTypeName, fieldName)
+			} else {
+				p.warning("field %v.%v has no presence, validator.required has no effect", ccTypeName, fieldName)
+			}
 		}
 		if repeated {
 			p.generateRepeatedCountValidator(variableName, ccTypeName, fieldName, fieldValidator)
+			p.generateCELValidator(message, field, variableName, fieldName, fieldValidator)
 			if field.IsMessage() || p.validatorWithNonRepeatedConstraint(fieldValidator) {
-				p.P(`for _, item := range `, variableName, `{`)
+				p.P(`for i, item := range `, variableName, `{`)
 				p.In()
+				p.element = &element{fieldName: fieldName, indexVariable: "i"}
 				variableName = "item"
 			}
 		} else if fieldValidator != nil {
 			if fieldValidator.RepeatedCountMin != nil {
-				fmt.Fprintf(os.Stderr, "WARNING: field %v.%v is not repeated, validator.min_elts has no effects\n", ccTypeName, fieldName)
+				p.warning("field %v.%v is not repeated, validator.min_elts has no effects", ccTypeName, fieldName)
 			}
 			if fieldValidator.RepeatedCountMax != nil {
-				fmt.Fprintf(os.Stderr, "WARNING: field %v.%v is not repeated, validator.max_elts has no effects\n", ccTypeName, fieldName)
+				p.warning("field %v.%v is not repeated, validator.max_elts has no effects", ccTypeName, fieldName)
+			}
+			if !field.IsMessage() {
+				p.generateCELValidator(message, field, variableName, fieldName, fieldValidator)
 			}
 		}
-		if field.IsString() {
-			p.generateStringValidator(variableName, ccTypeName, fieldName, fieldValidator)
-		} else if p.isSupportedInt(field) {
-			p.generateIntValidator(variableName, ccTypeName, fieldName, fieldValidator)
-		} else if p.isSupportedFloat(field) {
-			p.generateFloatValidator(variableName, ccTypeName, fieldName, fieldValidator)
-		} else if field.IsBytes() {
-			p.generateLengthValidator(variableName, ccTypeName, fieldName, fieldValidator)
+		if p.isSupportedScalar(field.FieldDescriptorProto) {
+			p.generateScalarValidator(field.FieldDescriptorProto, variableName, ccTypeName, fieldName, fieldValidator)
+		} else if field.IsEnum() {
+			p.generateEnumValidator(field.enum, variableName, fieldName, fieldValidator)
 		} else if field.IsMessage() {
 			if p.validatorWithMessageExists(fieldValidator) {
 				if nullable && !repeated {
 					p.P(`if nil == `, variableName, `{`)
 					p.In()
-					p.P(`return `, p.validatorPkg.Use(), `.FieldError("`, fieldName, `",`, p.fmtPkg.Use(), `.Errorf("message must exist"))`)
+					p.generateConstraintError("nil", fieldName, "msg_exists", true, p.fmtPkg.Use()+`.Errorf("message must exist")`)
 					p.Out()
 					p.P(`}`)
 				} else if repeated {
-					fmt.Fprintf(os.Stderr, "WARNING: field %v.%v is repeated, validator.msg_exists has no effect\n", ccTypeName, fieldName)
+					p.warning("field %v.%v is repeated, validator.msg_exists has no effect", ccTypeName, fieldName)
 				} else if !nullable {
-					fmt.Fprintf(os.Stderr, "WARNING: field %v.%v is a nullable=false, validator.msg_exists has no effect\n", ccTypeName, fieldName)
+					p.warning("field %v.%v is a nullable=false, validator.msg_exists has no effect", ccTypeName, fieldName)
 				}
 			}
 			if nullable {
@@ -305,9 +919,16 @@ func (p *plugin) generateProto3Message(file *generator.FileDescriptor, message *
 				// non-nullable fields in proto3 store actual structs, we need pointers to operate on interfaces
 				variableName = "&(" + variableName + ")"
 			}
-			p.P(`if err := `, p.validatorPkg.Use(), `.CallValidatorIfExists(`, variableName, `); err != nil {`)
+			if !repeated {
+				// Unlike other fields, messages are only checked by CEL expressions if they are set.
+				p.generateCELValidator(message, field, variableName, fieldName, fieldValidator)
+			}
+			p.generateTimeValidator(field.FieldDescriptorProto, field.stdtime || field.stdduration, variableName, fieldName, fieldValidator)
+			p.generateWrapperValidator(field.FieldDescriptorProto, variableName, ccTypeName, fieldName, fieldValidator)
+			p.generateAnyValidator(field.FieldDescriptorProto, variableName, nullable, fieldName, fieldValidator)
+			p.P(`if err := `, p.callValidator(), `(`, variableName, `); err != nil {`)
 			p.In()
-			p.P(`return `, p.validatorPkg.Use(), `.FieldError("`, fieldName, `", err)`)
+			p.generateErrorReturn(p.fieldErrorExpr(fieldName, "err"))
 			p.Out()
 			p.P(`}`)
 			if nullable {
@@ -317,6 +938,12 @@ func (p *plugin) generateProto3Message(file *generator.FileDescriptor, message *
 		}
 		if repeated && (field.IsMessage() || p.validatorWithNonRepeatedConstraint(fieldValidator)) {
 			// end the repeated loop
+			p.element = nil
+			p.Out()
+			p.P(`}`)
+		}
+		if presence {
+			// end the if around the optional field
 			p.Out()
 			p.P(`}`)
 		}
@@ -326,7 +953,119 @@ func (p *plugin) generateProto3Message(file *generator.FileDescriptor, message *
 			p.P(`}`)
 		}
 	}
-	p.P(`return nil`)
+	p.generateMessageValidator(message)
+	p.generateValidateFuncEnd()
+}
+
+// generateOneofValidator checks that a field of the oneof is set, if it's required.
+func (p *plugin) generateOneofValidator(oneof *goOneof) {
+	if !oneof.validator.GetRequired() {
+		return
+	}
+	p.P(`if this.`, oneof.goName, ` == nil {`)
+	p.In()
+	p.protoFieldName = oneof.name
+	p.generateConstraintError("nil", oneof.goName, "required", true, p.fmtPkg.Use()+`.Errorf("oneof must be set")`)
+	p.Out()
+	p.P(`}`)
+}
+
+// generateRequiredValidator checks that a field with presence is set, variableName being the pointer holding its value.
+func (p *plugin) generateRequiredValidator(variableName string, fieldName string, fv *validator.FieldValidator) {
+	if !fv.GetRequired() {
+		return
+	}
+	p.P(`if `, variableName, ` == nil {`)
+	p.In()
+	p.generateConstraintError("nil", fieldName, "required", true, p.fmtPkg.Use()+`.Errorf("value must be set")`)
+	p.Out()
+	p.P(`}`)
+}
+
+func (p *plugin) generateScalarValidator(field *descriptor.FieldDescriptorProto, variableName string, ccTypeName string, fieldName string, fv *validator.FieldValidator) {
+	if field.IsString() {
+		p.generateStringValidator(variableName, ccTypeName, fieldName, fv)
+	} else if p.isSupportedInt(field) {
+		p.generateIntValidator(variableName, ccTypeName, fieldName, fv)
+	} else if p.isSupportedFloat(field) {
+		p.generateFloatValidator(variableName, ccTypeName, fieldName, fv)
+	} else if field.IsBytes() {
+		p.generateLengthValidator(variableName, ccTypeName, fieldName, true, fv)
+	}
+}
+
+func (p *plugin) generateMapValidator(message *goMessage, variableName string, ccTypeName string, fieldName string, field *goField, fv *validator.FieldValidator) {
+	var keyField, valueField *descriptor.FieldDescriptorProto
+	for _, entryField := range field.mapEntry.Field {
+		switch entryField.GetNumber() {
+		case 1:
+			keyField = entryField
+		case 2:
+			valueField = entryField
+		}
+	}
+	if p.validatorWithNonMapConstraint(fv) {
+		p.warning("field %v.%v is a map, only validator.map_* constraints have an effect", ccTypeName, fieldName)
+	}
+	p.generateMapCountValidator(variableName, ccTypeName, fieldName, fv)
+	p.generateCELValidator(message, field, variableName, fieldName, fv)
+
+	keyValidator := fv.GetMapKey()
+	valueValidator := fv.GetMapValue()
+	checkKey := p.isSupportedScalar(keyField) && p.validatorWithNonRepeatedConstraint(keyValidator)
+	checkValue := valueField.IsMessage() || ((p.isSupportedScalar(valueField) || valueField.IsEnum()) && p.validatorWithNonRepeatedConstraint(valueValidator))
+	if !checkKey && !checkValue {
+		return
+	}
+	// The entries are checked in the order of their keys, so that the first violation doesn't vary between calls.
+	p.P(`for _, key := range `, p.validatorPkg.Use(), `.SortedMapKeys(`, variableName, `) {`)
+	p.In()
+	if checkValue {
+		p.P(`value := `, variableName, `[key]`)
+	}
+	p.element = &element{fieldName: fieldName, keyVariable: "key", constraintPrefix: "map_key."}
+	if checkKey {
+		p.generateScalarValidator(keyField, "key", ccTypeName+"_"+fieldName, "key", keyValidator)
+	}
+	p.element.constraintPrefix = "map_value."
+	if checkValue && !valueField.IsMessage() {
+		p.generateScalarValidator(valueField, "value", ccTypeName+"_"+fieldName, "value", valueValidator)
+		p.generateEnumValidator(field.enum, "value", "value", valueValidator)
+	}
+	if checkValue && valueField.IsMessage() {
+		// Map values are nullable unless the map field itself is marked as non-nullable and gogo is used.
+		nullable := field.nullable || !message.gogo
+		valueVariable := "value"
+		if nullable {
+			if p.validatorWithMessageExists(valueValidator) {
+				p.P(`if value == nil {`)
+				p.In()
+				p.generateConstraintError("nil", fieldName, "msg_exists", true, p.fmtPkg.Use()+`.Errorf("message must exist")`)
+				p.Out()
+				p.P(`}`)
+			}
+			p.P(`if value != nil {`)
+			p.In()
+		} else {
+			if p.validatorWithMessageExists(valueValidator) {
+				p.warning("field %v.%v is a nullable=false map, validator.msg_exists has no effect", ccTypeName, fieldName)
+			}
+			valueVariable = "&(value)"
+		}
+		p.generateTimeValidator(valueField, field.stdtime || field.stdduration, valueVariable, fieldName, valueValidator)
+		p.generateWrapperValidator(valueField, valueVariable, ccTypeName+"_"+fieldName, "value", valueValidator)
+		p.generateAnyValidator(valueField, valueVariable, nullable, fieldName, valueValidator)
+		p.P(`if err := `, p.callValidator(), `(`, valueVariable, `); err != nil {`)
+		p.In()
+		p.generateErrorReturn(p.fieldErrorExpr(fieldName, "err"))
+		p.Out()
+		p.P(`}`)
+		if nullable {
+			p.Out()
+			p.P(`}`)
+		}
+	}
+	p.element = nil
 	p.Out()
 	p.P(`}`)
 }
@@ -336,7 +1075,7 @@ func (p *plugin) generateIntValidator(variableName string, ccTypeName string, fi
 		p.P(`if !(`, variableName, ` > `, fv.IntGt, `) {`)
 		p.In()
 		errorStr := fmt.Sprintf(`be greater than '%d'`, fv.GetIntGt())
-		p.generateErrorString(variableName, fieldName, errorStr, fv)
+		p.generateErrorString(variableName, fieldName, "int_gt", fv.GetIntGt(), errorStr, fv)
 		p.Out()
 		p.P(`}`)
 	}
@@ -344,18 +1083,92 @@ func (p *plugin) generateIntValidator(variableName string, ccTypeName string, fi
 		p.P(`if !(`, variableName, ` < `, fv.IntLt, `) {`)
 		p.In()
 		errorStr := fmt.Sprintf(`be less than '%d'`, fv.GetIntLt())
-		p.generateErrorString(variableName, fieldName, errorStr, fv)
+		p.generateErrorString(variableName, fieldName, "int_lt", fv.GetIntLt(), errorStr, fv)
+		p.Out()
+		p.P(`}`)
+	}
+	if fv.IntGte != nil {
+		p.P(`if !(`, variableName, ` >= `, fv.IntGte, `) {`)
+		p.In()
+		errorStr := fmt.Sprintf(`be greater than or equal to '%d'`, fv.GetIntGte())
+		p.generateErrorString(variableName, fieldName, "int_gte", fv.GetIntGte(), errorStr, fv)
+		p.Out()
+		p.P(`}`)
+	}
+	if fv.IntLte != nil {
+		p.P(`if !(`, variableName, ` <= `, fv.IntLte, `) {`)
+		p.In()
+		errorStr := fmt.Sprintf(`be less than or equal to '%d'`, fv.GetIntLte())
+		p.generateErrorString(variableName, fieldName, "int_lte", fv.GetIntLte(), errorStr, fv)
+		p.Out()
+		p.P(`}`)
+	}
+	if len(fv.IntIn) > 0 {
+		p.P(`if !(`, inCondition(variableName, goLiterals(fv.IntIn)), `) {`)
+		p.In()
+		errorStr := fmt.Sprintf(`be one of the values %v`, fv.IntIn)
+		p.generateErrorString(variableName, fieldName, "int_in", fv.IntIn, errorStr, fv)
+		p.Out()
+		p.P(`}`)
+	}
+	if len(fv.IntNotIn) > 0 {
+		p.P(`if `, inCondition(variableName, goLiterals(fv.IntNotIn)), ` {`)
+		p.In()
+		errorStr := fmt.Sprintf(`not be one of the values %v`, fv.IntNotIn)
+		p.generateErrorString(variableName, fieldName, "int_not_in", fv.IntNotIn, errorStr, fv)
+		p.Out()
+		p.P(`}`)
+	}
+	if fv.IntConst != nil {
+		p.P(`if `, variableName, ` != `, fv.IntConst, ` {`)
+		p.In()
+		errorStr := fmt.Sprintf(`be equal to '%d'`, fv.GetIntConst())
+		p.generateErrorString(variableName, fieldName, "int_const", fv.GetIntConst(), errorStr, fv)
+		p.Out()
+		p.P(`}`)
+	}
+	if fv.UintGt != nil {
+		p.P(fmt.Sprint(`if !(`, variableName, ` > `, fv.GetUintGt(), `) {`))
+		p.In()
+		errorStr := fmt.Sprintf(`be greater than '%d'`, fv.GetUintGt())
+		p.generateErrorString(variableName, fieldName, "uint_gt", fv.GetUintGt(), errorStr, fv)
+		p.Out()
+		p.P(`}`)
+	}
+	if fv.UintLt != nil {
+		p.P(fmt.Sprint(`if !(`, variableName, ` < `, fv.GetUintLt(), `) {`))
+		p.In()
+		errorStr := fmt.Sprintf(`be less than '%d'`, fv.GetUintLt())
+		p.generateErrorString(variableName, fieldName, "uint_lt", fv.GetUintLt(), errorStr, fv)
+		p.Out()
+		p.P(`}`)
+	}
+	if fv.UintGte != nil {
+		p.P(fmt.Sprint(`if !(`, variableName, ` >= `, fv.GetUintGte(), `) {`))
+		p.In()
+		errorStr := fmt.Sprintf(`be greater than or equal to '%d'`, fv.GetUintGte())
+		p.generateErrorString(variableName, fieldName, "uint_gte", fv.GetUintGte(), errorStr, fv)
+		p.Out()
+		p.P(`}`)
+	}
+	if fv.UintLte != nil {
+		p.P(fmt.Sprint(`if !(`, variableName, ` <= `, fv.GetUintLte(), `) {`))
+		p.In()
+		errorStr := fmt.Sprintf(`be less than or equal to '%d'`, fv.GetUintLte())
+		p.generateErrorString(variableName, fieldName, "uint_lte", fv.GetUintLte(), errorStr, fv)
 		p.Out()
 		p.P(`}`)
 	}
 }
 
-func (p *plugin) generateLengthValidator(variableName string, ccTypeName string, fieldName string, fv *validator.FieldValidator) {
+// generateLengthValidator checks the length of strings and bytes. Strings with hex_bytes are hex encoded, so their
+// length is twice the number of bytes.
+func (p *plugin) generateLengthValidator(variableName string, ccTypeName string, fieldName string, isBytes bool, fv *validator.FieldValidator) {
 	if fv.LengthGt != nil {
 		p.P(`if !( len(`, variableName, `) > `, fv.LengthGt, `) {`)
 		p.In()
 		errorStr := fmt.Sprintf(`length be greater than '%d'`, fv.GetLengthGt())
-		p.generateErrorString(variableName, fieldName, errorStr, fv)
+		p.generateErrorString(variableName, fieldName, "length_gt", fv.GetLengthGt(), errorStr, fv)
 		p.Out()
 		p.P(`}`)
 	}
@@ -364,7 +1177,7 @@ func (p *plugin) generateLengthValidator(variableName string, ccTypeName string,
 		p.P(`if !( len(`, variableName, `) < `, fv.LengthLt, `) {`)
 		p.In()
 		errorStr := fmt.Sprintf(`length be less than '%d'`, fv.GetLengthLt())
-		p.generateErrorString(variableName, fieldName, errorStr, fv)
+		p.generateErrorString(variableName, fieldName, "length_lt", fv.GetLengthLt(), errorStr, fv)
 		p.Out()
 		p.P(`}`)
 	}
@@ -373,11 +1186,35 @@ func (p *plugin) generateLengthValidator(variableName string, ccTypeName string,
 		p.P(`if !( len(`, variableName, `) == `, fv.LengthEq, `) {`)
 		p.In()
 		errorStr := fmt.Sprintf(`length be not equal '%d'`, fv.GetLengthEq())
-		p.generateErrorString(variableName, fieldName, errorStr, fv)
+		p.generateErrorString(variableName, fieldName, "length_eq", fv.GetLengthEq(), errorStr, fv)
 		p.Out()
 		p.P(`}`)
 	}
 
+	if fv.HexBytes != nil {
+		length, errorStr := 2*fv.GetHexBytes(), fmt.Sprintf(`be the hex encoding of %d bytes`, fv.GetHexBytes())
+		if isBytes {
+			length, errorStr = fv.GetHexBytes(), fmt.Sprintf(`be %d bytes long`, fv.GetHexBytes())
+		}
+		p.P(`if len(`, variableName, `) != `, fmt.Sprint(length), ` {`)
+		p.In()
+		p.generateErrorString(variableName, fieldName, "hex_bytes", uint64(fv.GetHexBytes()), errorStr, fv)
+		p.Out()
+		p.P(`}`)
+	}
+
+	if isBytes && fv.BytesMessage != nil {
+		typeName := strings.TrimPrefix(fv.GetBytesMessage(), ".")
+		p.P(`if _, err := `, p.validatorPkg.Use(), `.UnmarshalMessage(`, strconv.Quote(typeName), `, `, variableName, `); err != nil {`)
+		p.In()
+		errorExpr := fmt.Sprint(p.fmtPkg.Use(), ".Errorf(`value must be a ", typeName, " message: %v`, err)")
+		if fv.GetHumanError() != "" {
+			errorExpr = fmt.Sprint(p.fmtPkg.Use(), ".Errorf(`", fv.GetHumanError(), "`)")
+		}
+		p.generateConstraintError(variableName, fieldName, "bytes_message", typeName, errorExpr)
+		p.Out()
+		p.P(`}`)
+	}
 }
 
 func (p *plugin) generateFloatValidator(variableName string, ccTypeName string, fieldName string, fv *validator.FieldValidator) {
@@ -386,10 +1223,10 @@ func (p *plugin) generateFloatValidator(variableName string, ccTypeName string,
 
 	// First check for incompatible constraints (i.e flt_lt & flt_lte both defined, etc) and determine the real limits.
 	if fv.FloatEpsilon != nil && fv.FloatLt == nil && fv.FloatGt == nil {
-		fmt.Fprintf(os.Stderr, "WARNING: field %v.%v has no 'float_lt' or 'float_gt' field so setting 'float_epsilon' has no effect.", ccTypeName, fieldName)
+		p.warning("field %v.%v has no 'float_lt' or 'float_gt' field so setting 'float_epsilon' has no effect.", ccTypeName, fieldName)
 	}
 	if fv.FloatLt != nil && fv.FloatLte != nil {
-		fmt.Fprintf(os.Stderr, "WARNING: field %v.%v has both 'float_lt' and 'float_lte' constraints, only the strictest will be used.", ccTypeName, fieldName)
+		p.warning("field %v.%v has both 'float_lt' and 'float_lte' constraints, only the strictest will be used.", ccTypeName, fieldName)
 		strictLimit := fv.GetFloatLt()
 		if fv.FloatEpsilon != nil {
 			strictLimit += fv.GetFloatEpsilon()
@@ -403,7 +1240,7 @@ func (p *plugin) generateFloatValidator(variableName string, ccTypeName string,
 	}
 
 	if fv.FloatGt != nil && fv.FloatGte != nil {
-		fmt.Fprintf(os.Stderr, "WARNING: field %v.%v has both 'float_gt' and 'float_gte' constraints, only the strictest will be used.", ccTypeName, fieldName)
+		p.warning("field %v.%v has both 'float_gt' and 'float_gte' constraints, only the strictest will be used.", ccTypeName, fieldName)
 		strictLimit := fv.GetFloatGt()
 		if fv.FloatEpsilon != nil {
 			strictLimit -= fv.GetFloatEpsilon()
@@ -417,44 +1254,98 @@ func (p *plugin) generateFloatValidator(variableName string, ccTypeName string,
 	}
 
 	// Generate the constraint checking code.
+	if fv.GetFloatNotNan() {
+		p.P(`if `, p.mathPkg.Use(), `.IsNaN(float64(`, variableName, `)) {`)
+		p.In()
+		p.generateErrorString(variableName, fieldName, "float_not_nan", true, "be a number", fv)
+		p.Out()
+		p.P(`}`)
+	}
+	if fv.GetFloatFinite() {
+		p.P(`if `, p.mathPkg.Use(), `.IsNaN(float64(`, variableName, `)) || `, p.mathPkg.Use(), `.IsInf(float64(`, variableName, `), 0) {`)
+		p.In()
+		p.generateErrorString(variableName, fieldName, "float_finite", true, "be finite", fv)
+		p.Out()
+		p.P(`}`)
+	}
 	errorStr := ""
 	compareStr := ""
+	constraint := ""
+	constraintValue := 0.0
 	if fv.FloatGt != nil || fv.FloatGte != nil {
-		compareStr = fmt.Sprint(`if !(`, variableName)
+		compareStr = variableName
 		if lowerIsStrict {
+			constraint, constraintValue = "float_gt", fv.GetFloatGt()
 			errorStr = fmt.Sprintf(`be strictly greater than '%g'`, fv.GetFloatGt())
 			if fv.FloatEpsilon != nil {
 				errorStr += fmt.Sprintf(` with a tolerance of '%g'`, fv.GetFloatEpsilon())
 				compareStr += fmt.Sprint(` + `, fv.GetFloatEpsilon())
 			}
-			compareStr += fmt.Sprint(` > `, fv.GetFloatGt(), `) {`)
+			compareStr = p.floatComparison(compareStr, ` > `, fv.GetFloatGt())
 		} else {
+			constraint, constraintValue = "float_gte", fv.GetFloatGte()
 			errorStr = fmt.Sprintf(`be greater than or equal to '%g'`, fv.GetFloatGte())
-			compareStr += fmt.Sprint(` >= `, fv.GetFloatGte(), `) {`)
+			compareStr = p.floatComparison(compareStr, ` >= `, fv.GetFloatGte())
 		}
 		p.P(compareStr)
 		p.In()
-		p.generateErrorString(variableName, fieldName, errorStr, fv)
+		p.generateErrorString(variableName, fieldName, constraint, constraintValue, errorStr, fv)
 		p.Out()
 		p.P(`}`)
 	}
 
 	if fv.FloatLt != nil || fv.FloatLte != nil {
-		compareStr = fmt.Sprint(`if !(`, variableName)
+		compareStr = variableName
 		if upperIsStrict {
+			constraint, constraintValue = "float_lt", fv.GetFloatLt()
 			errorStr = fmt.Sprintf(`be strictly lower than '%g'`, fv.GetFloatLt())
 			if fv.FloatEpsilon != nil {
 				errorStr += fmt.Sprintf(` with a tolerance of '%g'`, fv.GetFloatEpsilon())
 				compareStr += fmt.Sprint(` - `, fv.GetFloatEpsilon())
 			}
-			compareStr += fmt.Sprint(` < `, fv.GetFloatLt(), `) {`)
+			compareStr = p.floatComparison(compareStr, ` < `, fv.GetFloatLt())
 		} else {
+			constraint, constraintValue = "float_lte", fv.GetFloatLte()
 			errorStr = fmt.Sprintf(`be lower than or equal to '%g'`, fv.GetFloatLte())
-			compareStr += fmt.Sprint(` <= `, fv.GetFloatLte(), `) {`)
+			compareStr = p.floatComparison(compareStr, ` <= `, fv.GetFloatLte())
 		}
 		p.P(compareStr)
 		p.In()
-		p.generateErrorString(variableName, fieldName, errorStr, fv)
+		p.generateErrorString(variableName, fieldName, constraint, constraintValue, errorStr, fv)
+		p.Out()
+		p.P(`}`)
+	}
+}
+
+// floatComparison returns the if statement that checks the float expression x against bound using operator.
+// Infinite bounds aren't constants, they are compared as float64 values.
+func (p *plugin) floatComparison(x string, operator string, bound float64) string {
+	if math.IsInf(bound, 0) {
+		return fmt.Sprint(`if !(float64(`, x, `)`, operator, p.floatLiteral(bound), `) {`)
+	}
+	return fmt.Sprint(`if !(`, x, operator, bound, `) {`)
+}
+
+// generateRuneLengthValidator checks the number of Unicode code points of the string in variableName.
+func (p *plugin) generateRuneLengthValidator(variableName string, fieldName string, fv *validator.FieldValidator) {
+	for _, c := range []struct {
+		constraint string
+		value      *int64
+		operator   string
+		errorStr   string
+	}{
+		{"rune_length_gt", fv.RuneLengthGt, ">", "be longer than '%d' characters"},
+		{"rune_length_lt", fv.RuneLengthLt, "<", "be shorter than '%d' characters"},
+		{"rune_length_eq", fv.RuneLengthEq, "==", "be '%d' characters long"},
+		{"min_runes", fv.MinRunes, ">=", "be at least '%d' characters long"},
+		{"max_runes", fv.MaxRunes, "<=", "be at most '%d' characters long"},
+	} {
+		if c.value == nil {
+			continue
+		}
+		p.P(`if !(`, p.utf8Pkg.Use(), `.RuneCountInString(`, variableName, `) `, c.operator, ` `, fmt.Sprint(*c.value), `) {`)
+		p.In()
+		p.generateErrorString(variableName, fieldName, c.constraint, *c.value, fmt.Sprintf(c.errorStr, *c.value), fv)
 		p.Out()
 		p.P(`}`)
 	}
@@ -465,7 +1356,7 @@ func (p *plugin) generateStringValidator(variableName string, ccTypeName string,
 		p.P(`if !`, p.regexName(ccTypeName, fieldName), `.MatchString(`, variableName, `) {`)
 		p.In()
 		errorStr := "be a string conforming to regex " + strconv.Quote(fv.GetRegex())
-		p.generateErrorString(variableName, fieldName, errorStr, fv)
+		p.generateErrorString(variableName, fieldName, "regex", fv.GetRegex(), errorStr, fv)
 		p.Out()
 		p.P(`}`)
 	}
@@ -473,11 +1364,13 @@ func (p *plugin) generateStringValidator(variableName string, ccTypeName string,
 		p.P(`if `, variableName, ` == "" {`)
 		p.In()
 		errorStr := "not be an empty string"
-		p.generateErrorString(variableName, fieldName, errorStr, fv)
+		p.generateErrorString(variableName, fieldName, "string_not_empty", true, errorStr, fv)
 		p.Out()
 		p.P(`}`)
 	}
-	p.generateLengthValidator(variableName, ccTypeName, fieldName, fv)
+	p.generateStringFormatValidator(variableName, fieldName, fv)
+	p.generateLengthValidator(variableName, ccTypeName, fieldName, false, fv)
+	p.generateRuneLengthValidator(variableName, fieldName, fv)
 
 }
 
@@ -490,7 +1383,7 @@ func (p *plugin) generateRepeatedCountValidator(variableName string, ccTypeName
 		p.P(compareStr)
 		p.In()
 		errorStr := fmt.Sprint(`contain at least `, fv.GetRepeatedCountMin(), ` elements`)
-		p.generateErrorString(variableName, fieldName, errorStr, fv)
+		p.generateErrorString(variableName, fieldName, "repeated_count_min", fv.GetRepeatedCountMin(), errorStr, fv)
 		p.Out()
 		p.P(`}`)
 	}
@@ -499,22 +1392,144 @@ func (p *plugin) generateRepeatedCountValidator(variableName string, ccTypeName
 		p.P(compareStr)
 		p.In()
 		errorStr := fmt.Sprint(`contain at most `, fv.GetRepeatedCountMax(), ` elements`)
-		p.generateErrorString(variableName, fieldName, errorStr, fv)
+		p.generateErrorString(variableName, fieldName, "repeated_count_max", fv.GetRepeatedCountMax(), errorStr, fv)
 		p.Out()
 		p.P(`}`)
 	}
 }
 
-func (p *plugin) generateErrorString(variableName string, fieldName string, specificError string, fv *validator.FieldValidator) {
+func (p *plugin) generateMapCountValidator(variableName string, ccTypeName string, fieldName string, fv *validator.FieldValidator) {
+	if fv == nil {
+		return
+	}
+	if fv.MapCountMin != nil {
+		compareStr := fmt.Sprint(`if len(`, variableName, `) < `, fv.GetMapCountMin(), ` {`)
+		p.P(compareStr)
+		p.In()
+		errorStr := fmt.Sprint(`contain at least `, fv.GetMapCountMin(), ` entries`)
+		p.generateErrorString(variableName, fieldName, "map_count_min", fv.GetMapCountMin(), errorStr, fv)
+		p.Out()
+		p.P(`}`)
+	}
+	if fv.MapCountMax != nil {
+		compareStr := fmt.Sprint(`if len(`, variableName, `) > `, fv.GetMapCountMax(), ` {`)
+		p.P(compareStr)
+		p.In()
+		errorStr := fmt.Sprint(`contain at most `, fv.GetMapCountMax(), ` entries`)
+		p.generateErrorString(variableName, fieldName, "map_count_max", fv.GetMapCountMax(), errorStr, fv)
+		p.Out()
+		p.P(`}`)
+	}
+}
+
+func (p *plugin) generateErrorString(variableName string, fieldName string, constraint string, constraintValue interface{}, specificError string, fv *validator.FieldValidator) {
+	var errorExpr string
 	if fv.GetHumanError() == "" {
-		p.P(`return `, p.validatorPkg.Use(), `.FieldError("`, fieldName, `",`, p.fmtPkg.Use(), ".Errorf(`value '%v' must ", specificError, "`", `, `, variableName, `))`)
+		errorExpr = fmt.Sprint(p.fmtPkg.Use(), ".Errorf(`value '%v' must ", specificError, "`", `, `, variableName, `)`)
+	} else {
+		errorExpr = fmt.Sprint(p.fmtPkg.Use(), ".Errorf(`", fv.GetHumanError(), "`)")
+	}
+	p.generateConstraintError(variableName, fieldName, constraint, constraintValue, errorExpr)
+}
+
+// generateConstraintError reports a violation of the named constraint by the value in variableName.
+func (p *plugin) generateConstraintError(variableName string, fieldName string, constraint string, constraintValue interface{}, errorExpr string) {
+	if p.element != nil {
+		constraint = p.element.constraintPrefix + constraint
+	}
+	var literal string
+	if f, ok := constraintValue.(float64); ok {
+		literal = p.floatLiteral(f)
 	} else {
-		p.P(`return `, p.validatorPkg.Use(), `.FieldError("`, fieldName, `",`, p.fmtPkg.Use(), ".Errorf(`", fv.GetHumanError(), "`))")
+		literal = goLiteral(constraintValue)
+	}
+	violationExpr := fmt.Sprint(p.validatorPkg.Use(), `.ConstraintError("`, constraint, `", `, literal, `, `, variableName, `, `, errorExpr, `)`)
+	p.generateErrorReturn(p.fieldErrorExpr(fieldName, violationExpr))
+}
+
+// fieldErrorExpr wraps errorExpr with the Go and .proto names of the field whose checks are being generated.
+func (p *plugin) fieldErrorExpr(fieldName string, errorExpr string) string {
+	switch {
+	case p.element != nil && p.element.indexVariable != "":
+		return p.validatorPkg.Use() + `.ProtoIndexFieldError("` + p.element.fieldName + `", "` + p.protoFieldName + `", ` + p.element.indexVariable + `, ` + errorExpr + `)`
+	case p.element != nil:
+		return p.validatorPkg.Use() + `.ProtoMapFieldError("` + p.element.fieldName + `", "` + p.protoFieldName + `", ` + p.element.keyVariable + `, ` + errorExpr + `)`
+	}
+	return p.validatorPkg.Use() + `.ProtoFieldError("` + fieldName + `", "` + p.protoFieldName + `", ` + errorExpr + `)`
+}
+
+// generateErrorReturn returns the given error from Validate, or adds it to the collected errors in ValidateAll.
+func (p *plugin) generateErrorReturn(errorExpr string) {
+	if p.validateAll {
+		p.P(`errs.Add(`, errorExpr, `)`)
+	} else {
+		p.P(`return `, errorExpr)
+	}
+}
+
+func (p *plugin) generateValidateFuncStart(ccTypeName string) {
+	if p.validateAll {
+		p.P(`func (this *`, ccTypeName, `) ValidateAll() error {`)
+		p.In()
+		p.P(`var errs `, p.validatorPkg.Use(), `.Errors`)
+	} else {
+		p.P(`func (this *`, ccTypeName, `) Validate() error {`)
+		p.In()
+	}
+}
+
+func (p *plugin) generateValidateFuncEnd() {
+	if p.validateAll {
+		p.P(`return errs.ErrorOrNil()`)
+	} else {
+		p.P(`return nil`)
+	}
+	p.Out()
+	p.P(`}`)
+}
+
+// callValidator returns the function used to validate nested messages.
+func (p *plugin) callValidator() string {
+	if p.validateAll {
+		return p.validatorPkg.Use() + `.CallValidateAllIfExists`
+	}
+	return p.validatorPkg.Use() + `.CallValidatorIfExists`
+}
+
+// warning reports a problem with the validator annotations that doesn't prevent code generation.
+func (p *plugin) warning(format string, args ...interface{}) {
+	if p.validateAll {
+		// Already reported while generating Validate.
+		return
+	}
+	if p.warningsAsErrors {
+		p.fail(format, args...)
+		return
 	}
+	fmt.Fprintf(os.Stderr, "WARNING: "+format+"\n", args...)
+}
 
+// fail reports a problem with the validator annotations that prevents code generation.
+func (p *plugin) fail(format string, args ...interface{}) {
+	if p.Generator != nil {
+		p.Fail(fmt.Sprintf(format, args...))
+	}
+	if p.err == nil {
+		p.err = fmt.Errorf(format, args...)
+	}
+}
+
+func (p *plugin) warnIfMapConstraint(ccTypeName string, fieldName string, fv *validator.FieldValidator) {
+	if fv == nil {
+		return
+	}
+	if fv.MapCountMin != nil || fv.MapCountMax != nil || fv.MapKey != nil || fv.MapValue != nil {
+		p.warning("field %v.%v is not a map, validator.map_* constraints have no effect", ccTypeName, fieldName)
+	}
 }
 
-func (p *plugin) fieldIsProto3Map(file *generator.FileDescriptor, message *generator.Descriptor, field *descriptor.FieldDescriptorProto) bool {
+// mapEntryMessage returns the automatically generated map entry type of the field, or nil if the field is not a map.
+func (p *plugin) mapEntryMessage(file *generator.FileDescriptor, message *generator.Descriptor, field *descriptor.FieldDescriptorProto) *descriptor.DescriptorProto {
 	// Context from descriptor.proto
 	// Whether the message is an automatically generated map entry type for the
 	// maps field.
@@ -538,7 +1553,7 @@ func (p *plugin) fieldIsProto3Map(file *generator.FileDescriptor, message *gener
 	// instead. The option should only be implicitly set by the proto compiler
 	// parser.
 	if field.GetType() != descriptor.FieldDescriptorProto_TYPE_MESSAGE || !field.IsRepeated() {
-		return false
+		return nil
 	}
 	typeName := field.GetTypeName()
 	var msg *descriptor.DescriptorProto
@@ -549,7 +1564,10 @@ func (p *plugin) fieldIsProto3Map(file *generator.FileDescriptor, message *gener
 		// Nested, relative case.
 		msg = file.GetNestedMessage(message.DescriptorProto, field.GetTypeName())
 	}
-	return msg.GetOptions().GetMapEntry()
+	if !msg.GetOptions().GetMapEntry() {
+		return nil
+	}
+	return msg
 }
 
 func (p *plugin) validatorWithAnyConstraint(fv *validator.FieldValidator) bool {
@@ -579,13 +1597,104 @@ func (p *plugin) validatorWithNonRepeatedConstraint(fv *validator.FieldValidator
 	// Need to use reflection in order to be future-proof for new types of constraints.
 	v := reflect.ValueOf(*fv)
 	for i := 0; i < v.NumField(); i++ {
-		if v.Type().Field(i).Name != "RepeatedCountMin" && v.Type().Field(i).Name != "RepeatedCountMax" && v.Field(i).Pointer() != 0 {
+		switch v.Type().Field(i).Name {
+		case "RepeatedCountMin", "RepeatedCountMax", "Cel":
+			// Checked on the whole field.
+			continue
+		}
+		if v.Field(i).Pointer() != 0 {
 			return true
 		}
 	}
 	return false
 }
 
+func (p *plugin) validatorWithNonMapConstraint(fv *validator.FieldValidator) bool {
+	if fv == nil {
+		return false
+	}
+
+	// Need to use reflection in order to be future-proof for new types of constraints.
+	v := reflect.ValueOf(*fv)
+	for i := 0; i < v.NumField(); i++ {
+		switch v.Type().Field(i).Name {
+		case "MapCountMin", "MapCountMax", "MapKey", "MapValue", "HumanError", "Cel":
+			continue
+		}
+		if v.Field(i).Pointer() != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *plugin) isSupportedScalar(field *descriptor.FieldDescriptorProto) bool {
+	return field.IsString() || p.isSupportedInt(field) || p.isSupportedFloat(field) || field.IsBytes()
+}
+
+// goLiteral returns the Go source representation of a constraint value. Floats are printed by floatLiteral.
+func goLiteral(v interface{}) string {
+	switch v := v.(type) {
+	case int64:
+		return fmt.Sprintf("int64(%d)", v)
+	case uint64:
+		return fmt.Sprintf("uint64(%d)", v)
+	case string:
+		return strconv.Quote(v)
+	case bool:
+		return strconv.FormatBool(v)
+	case []string:
+		return "[]string{" + strings.Join(goLiterals(v), ", ") + "}"
+	case []int32:
+		return "[]int32{" + strings.Join(goLiterals(v), ", ") + "}"
+	case []int64:
+		return "[]int64{" + strings.Join(goLiterals(v), ", ") + "}"
+	}
+	panic(fmt.Sprintf("unsupported constraint value type %T", v))
+}
+
+// floatLiteral returns the Go source representation of a float64 constraint value, which may not be finite.
+func (p *plugin) floatLiteral(v float64) string {
+	switch {
+	case math.IsNaN(v):
+		return p.mathPkg.Use() + ".NaN()"
+	case math.IsInf(v, 1):
+		return p.mathPkg.Use() + ".Inf(1)"
+	case math.IsInf(v, -1):
+		return p.mathPkg.Use() + ".Inf(-1)"
+	}
+	return "float64(" + strconv.FormatFloat(v, 'g', -1, 64) + ")"
+}
+
+// goLiterals returns the Go source representations of the elements of a string or integer slice.
+func goLiterals(v interface{}) []string {
+	var literals []string
+	switch v := v.(type) {
+	case []string:
+		for _, s := range v {
+			literals = append(literals, strconv.Quote(s))
+		}
+	case []int32:
+		for _, i := range v {
+			literals = append(literals, strconv.Itoa(int(i)))
+		}
+	case []int64:
+		for _, i := range v {
+			literals = append(literals, strconv.FormatInt(i, 10))
+		}
+	}
+	return literals
+}
+
+// inCondition returns the condition that variableName is equal to one of the literals.
+func inCondition(variableName string, literals []string) string {
+	conditions := make([]string, len(literals))
+	for i, literal := range literals {
+		conditions[i] = variableName + ` == ` + literal
+	}
+	return strings.Join(conditions, ` || `)
+}
+
 func (p *plugin) regexName(ccTypeName string, fieldName string) string {
 	return "_regex_" + ccTypeName + "_" + fieldName
 }
@@ -0,0 +1,137 @@
+// Copyright 2016 Michal Witkowski. All Rights Reserved.
+// See LICENSE for licensing terms.
+
+package plugin
+
+import (
+	"math"
+	"testing"
+
+	gogoproto "github.com/gogo/protobuf/proto"
+	descriptor "github.com/gogo/protobuf/protoc-gen-gogo/descriptor"
+	validator "github.com/mwitkow/go-proto-validators"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/compiler/protogen"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/pluginpb"
+)
+
+// generate generates the validators of a proto3 message with a single field, returning the generation error if any.
+func generate(t *testing.T, opts Options, fieldType descriptorpb.FieldDescriptorProto_Type, fv *validator.FieldValidator) string {
+	gogoOptions := &descriptor.FieldOptions{}
+	if err := gogoproto.SetExtension(gogoOptions, validator.E_Field, fv); err != nil {
+		t.Fatalf("failed to set the field options: %v", err)
+	}
+	options := &descriptorpb.FieldOptions{}
+	convertFromGogo(t, gogoOptions, options)
+	file := &descriptorpb.FileDescriptorProto{
+		Name:    proto.String("generate.proto"),
+		Package: proto.String("generate"),
+		Syntax:  proto.String("proto3"),
+		Options: &descriptorpb.FileOptions{GoPackage: proto.String("example.com/generate")},
+		MessageType: []*descriptorpb.DescriptorProto{{
+			Name: proto.String("Message"),
+			Field: []*descriptorpb.FieldDescriptorProto{{
+				Name:     proto.String("value"),
+				Number:   proto.Int32(1),
+				Label:    descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+				Type:     fieldType.Enum(),
+				JsonName: proto.String("value"),
+				Options:  options,
+			}},
+		}},
+	}
+	gen, err := protogen.Options{}.New(&pluginpb.CodeGeneratorRequest{
+		FileToGenerate: []string{file.GetName()},
+		ProtoFile:      []*descriptorpb.FileDescriptorProto{file},
+	})
+	if err != nil {
+		t.Fatalf("failed to create the generator: %v", err)
+	}
+	for _, f := range gen.Files {
+		if f.Generate {
+			opts.GenerateFile(gen, f)
+		}
+	}
+	return gen.Response().GetError()
+}
+
+func convertFromGogo(t *testing.T, from gogoproto.Message, to proto.Message) {
+	b, err := gogoproto.Marshal(from)
+	if err != nil {
+		t.Fatalf("failed to marshal: %v", err)
+	}
+	if err := proto.Unmarshal(b, to); err != nil {
+		t.Fatalf("failed to unmarshal: %v", err)
+	}
+}
+
+func TestGenerate_Failures(t *testing.T) {
+	double := descriptorpb.FieldDescriptorProto_TYPE_DOUBLE
+	for _, tc := range []struct {
+		name      string
+		fieldType descriptorpb.FieldDescriptorProto_Type
+		fv        *validator.FieldValidator
+		err       string
+	}{
+		{
+			name:      "BadType",
+			fieldType: descriptorpb.FieldDescriptorProto_TYPE_STRING,
+			fv:        &validator.FieldValidator{IntGt: gogoproto.Int64(1)},
+			err:       "field Message.Value has type TYPE_STRING, validator.int_gt doesn't apply to it",
+		},
+		{
+			name:      "EmptyIntRange",
+			fieldType: descriptorpb.FieldDescriptorProto_TYPE_INT64,
+			fv:        &validator.FieldValidator{IntGt: gogoproto.Int64(5), IntLt: gogoproto.Int64(6)},
+			err:       "field Message.Value has validator.int_* bounds that no value satisfies",
+		},
+		{
+			name:      "EmptyFloatRange",
+			fieldType: double,
+			fv:        &validator.FieldValidator{FloatGt: gogoproto.Float64(2), FloatLt: gogoproto.Float64(1)},
+			err:       "field Message.Value has validator.float_* bounds that no value satisfies",
+		},
+		{
+			name:      "FloatGtInf",
+			fieldType: double,
+			fv:        &validator.FieldValidator{FloatGt: gogoproto.Float64(math.Inf(1))},
+			err:       "field Message.Value has validator.float_* bounds that no value satisfies",
+		},
+		{
+			name:      "FloatLtMinusInf",
+			fieldType: double,
+			fv:        &validator.FieldValidator{FloatLt: gogoproto.Float64(math.Inf(-1))},
+			err:       "field Message.Value has validator.float_* bounds that no value satisfies",
+		},
+		{
+			name:      "FloatLtNaN",
+			fieldType: double,
+			fv:        &validator.FieldValidator{FloatLt: gogoproto.Float64(math.NaN())},
+			err:       "field Message.Value has a validator.float_lt of NaN, which no value satisfies",
+		},
+		{
+			name:      "FloatEpsilonInf",
+			fieldType: double,
+			fv:        &validator.FieldValidator{FloatGt: gogoproto.Float64(0), FloatEpsilon: gogoproto.Float64(math.Inf(1))},
+			err:       "field Message.Value has a validator.float_epsilon that isn't finite",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.err, generate(t, Options{}, tc.fieldType, tc.fv))
+		})
+	}
+}
+
+func TestGenerate_InfiniteFloatBounds(t *testing.T) {
+	fv := &validator.FieldValidator{FloatGte: gogoproto.Float64(math.Inf(1)), FloatLte: gogoproto.Float64(math.Inf(1))}
+	assert.Empty(t, generate(t, Options{}, descriptorpb.FieldDescriptorProto_TYPE_DOUBLE, fv))
+}
+
+func TestGenerate_WarningsAsErrors(t *testing.T) {
+	fv := &validator.FieldValidator{FloatEpsilon: gogoproto.Float64(0.1)}
+	assert.Empty(t, generate(t, Options{}, descriptorpb.FieldDescriptorProto_TYPE_DOUBLE, fv))
+	assert.Equal(t, "field Message.Value has no 'float_lt' or 'float_gt' field so setting 'float_epsilon' has no effect.",
+		generate(t, Options{WarningsAsErrors: true}, descriptorpb.FieldDescriptorProto_TYPE_DOUBLE, fv))
+}
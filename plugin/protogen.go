@@ -0,0 +1,163 @@
+// Copyright 2016 Michal Witkowski. All Rights Reserved.
+// See LICENSE for licensing terms.
+
+package plugin
+
+import (
+	"reflect"
+	"strings"
+
+	gogoproto "github.com/gogo/protobuf/proto"
+	descriptor "github.com/gogo/protobuf/protoc-gen-gogo/descriptor"
+	"google.golang.org/protobuf/compiler/protogen"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+)
+
+// Options configure the generation of validators for the Go types generated by protoc-gen-go.
+type Options struct {
+	// WarningsAsErrors makes warnings about the validator annotations prevent code generation.
+	WarningsAsErrors bool
+	// LazyRegex makes the generated regexes compile when first used rather than when their package is initialized.
+	LazyRegex bool
+}
+
+// GenerateFile generates the validators of the messages in file, for the Go types generated by protoc-gen-go.
+// The file is named and placed like the .pb.go file of protoc-gen-go, with a .validator.pb.go suffix.
+func GenerateFile(gen *protogen.Plugin, file *protogen.File) *protogen.GeneratedFile {
+	return Options{}.GenerateFile(gen, file)
+}
+
+// GenerateFile generates the validators of the messages in file, like the GenerateFile function.
+func (opts Options) GenerateFile(gen *protogen.Plugin, file *protogen.File) *protogen.GeneratedFile {
+	g := gen.NewGeneratedFile(file.GeneratedFilenamePrefix+".validator.pb.go", file.GoImportPath)
+	g.P("// Code generated by protoc-gen-govalidators. DO NOT EDIT.")
+	g.P("// source: ", file.Desc.Path())
+	g.P()
+	g.P("package ", file.GoPackageName)
+	g.P()
+
+	p := &plugin{
+		out:          protogenPrinter{g},
+		regexPkg:     protogenImport{g, "regexp"},
+		fmtPkg:       protogenImport{g, "fmt"},
+		validatorPkg: protogenImport{g, "github.com/mwitkow/go-proto-validators"},
+		celPkg:       protogenImport{g, "github.com/mwitkow/go-proto-validators/cel"},
+		timePkg:      protogenImport{g, "time"},
+		mathPkg:      protogenImport{g, "math"},
+		utf8Pkg:      protogenImport{g, "unicode/utf8"},
+
+		warningsAsErrors: opts.WarningsAsErrors,
+		lazyRegex:        opts.LazyRegex,
+	}
+	var generateMessages func(messages []*protogen.Message)
+	generateMessages = func(messages []*protogen.Message) {
+		for _, message := range messages {
+			if message.Desc.IsMapEntry() {
+				continue
+			}
+			p.generateMessage(p.protogenMessage(file, message))
+			generateMessages(message.Messages)
+		}
+	}
+	generateMessages(file.Messages)
+	if p.err != nil {
+		gen.Error(p.err)
+	}
+	return g
+}
+
+// protogenMessage describes a message using the names assigned by protoc-gen-go.
+func (p *plugin) protogenMessage(file *protogen.File, message *protogen.Message) *goMessage {
+	msg := &goMessage{
+		typeName: message.GoIdent.GoName,
+		fileName: file.Desc.Path(),
+		proto3:   file.Proto.GetSyntax() == "proto3",
+		fullName: string(message.Desc.FullName()),
+		desc:     message.Desc,
+	}
+	desc := &descriptor.DescriptorProto{}
+	convertDescriptor(protodesc.ToDescriptorProto(message.Desc), desc)
+	msg.validator = getMessageValidatorIfAny(desc)
+	for i, oneof := range message.Oneofs {
+		msg.oneofs = append(msg.oneofs, &goOneof{name: string(oneof.Desc.Name()), goName: oneof.GoName, validator: getOneofValidatorIfAny(desc.OneofDecl[i])})
+	}
+	fileOptions := &descriptor.FileOptions{}
+	convertDescriptor(file.Desc.Options(), fileOptions)
+	fileValidator := getFileValidatorIfAny(fileOptions)
+	for i, field := range message.Fields {
+		f := &goField{
+			FieldDescriptorProto: desc.Field[i],
+			goName:               field.GoName,
+			oneOfFieldName:       field.GoName,
+			// protoc-gen-go ignores the gogoproto options.
+			nullable: true,
+		}
+		if field.Oneof != nil && !field.Oneof.Desc.IsSynthetic() {
+			f.goName = field.Oneof.GoName
+			f.oneOfTypeName = field.GoIdent.GoName
+		}
+		if field.Desc.HasOptionalKeyword() && msg.proto3 {
+			// proto3 optional fields are wrapped in a synthetic oneof, which doesn't exist in the Go types.
+			f.optional = true
+			f.OneofIndex = nil
+		}
+		enum := field.Enum
+		if field.Desc.IsMap() {
+			enum = field.Message.Fields[1].Enum
+		}
+		if enum != nil {
+			f.enum = &descriptor.EnumDescriptorProto{}
+			convertDescriptor(protodesc.ToEnumDescriptorProto(enum.Desc), f.enum)
+		}
+		if field.Desc.IsMap() {
+			f.mapEntry = &descriptor.DescriptorProto{}
+			convertDescriptor(protodesc.ToDescriptorProto(field.Message.Desc), f.mapEntry)
+		}
+		f.FieldDescriptorProto = p.withFileDefaults(f.FieldDescriptorProto, f.mapEntry, fileValidator)
+		msg.fields = append(msg.fields, f)
+	}
+	return msg
+}
+
+// convertDescriptor converts a descriptor to its gogo counterpart, on which the validator options are registered.
+func convertDescriptor(from proto.Message, to gogoproto.Message) {
+	b, err := proto.Marshal(from)
+	if err != nil {
+		panic(err)
+	}
+	if err := gogoproto.Unmarshal(b, to); err != nil {
+		panic(err)
+	}
+}
+
+// protogenPrinter adapts a protogen.GeneratedFile to the gogo generator's way of printing.
+// Indentation is left to protogen, which formats the generated code.
+type protogenPrinter struct {
+	*protogen.GeneratedFile
+}
+
+func (p protogenPrinter) P(args ...interface{}) {
+	for i, arg := range args {
+		// The gogo generator prints the values of pointer arguments, e.g. constraints of the validator options.
+		if v := reflect.ValueOf(arg); v.Kind() == reflect.Ptr && !v.IsNil() {
+			args[i] = v.Elem().Interface()
+		}
+	}
+	p.GeneratedFile.P(args...)
+}
+
+func (p protogenPrinter) In() {}
+
+func (p protogenPrinter) Out() {}
+
+// protogenImport is a package imported by a protogen.GeneratedFile.
+type protogenImport struct {
+	g    *protogen.GeneratedFile
+	path protogen.GoImportPath
+}
+
+func (i protogenImport) Use() string {
+	// QualifiedGoIdent imports the package, the qualifier of an empty identifier is the package name.
+	return strings.TrimSuffix(i.g.QualifiedGoIdent(i.path.Ident("")), ".")
+}
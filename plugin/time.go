@@ -0,0 +1,150 @@
+// Copyright 2016 Michal Witkowski. All Rights Reserved.
+// See LICENSE for licensing terms.
+
+package plugin
+
+import (
+	"fmt"
+	"time"
+
+	descriptor "github.com/gogo/protobuf/protoc-gen-gogo/descriptor"
+	"github.com/mwitkow/go-proto-validators"
+)
+
+const (
+	timestampType = ".google.protobuf.Timestamp"
+	durationType  = ".google.protobuf.Duration"
+)
+
+// checkTimeConstraints reports Timestamp and Duration constraints that can't be parsed or satisfied.
+func (p *plugin) checkTimeConstraints(name string, fv *validator.FieldValidator) {
+	if fv.GetTimestampLtNow() && fv.GetTimestampGtNow() {
+		p.fail("field %v has both validator.timestamp_lt_now and validator.timestamp_gt_now, no timestamp satisfies them", name)
+	}
+	if fv.TimestampWithin != nil {
+		if d, err := time.ParseDuration(fv.GetTimestampWithin()); err != nil {
+			p.fail("field %v has an invalid validator.timestamp_within: %v", name, err)
+		} else if d < 0 {
+			p.fail("field %v has a negative validator.timestamp_within", name)
+		}
+	}
+	gt, gtErr := parseTimestamp(fv.TimestampGt)
+	lt, ltErr := parseTimestamp(fv.TimestampLt)
+	switch {
+	case gtErr != nil:
+		p.fail("field %v has an invalid validator.timestamp_gt: %v", name, gtErr)
+	case ltErr != nil:
+		p.fail("field %v has an invalid validator.timestamp_lt: %v", name, ltErr)
+	case fv.TimestampGt != nil && fv.TimestampLt != nil && !gt.Before(lt.Add(-time.Nanosecond)):
+		p.fail("field %v has no timestamp both after validator.timestamp_gt and before validator.timestamp_lt", name)
+	}
+	durationGt, gtErr := parseDuration(fv.DurationGt)
+	durationLt, ltErr := parseDuration(fv.DurationLt)
+	switch {
+	case gtErr != nil:
+		p.fail("field %v has an invalid validator.duration_gt: %v", name, gtErr)
+	case ltErr != nil:
+		p.fail("field %v has an invalid validator.duration_lt: %v", name, ltErr)
+	case fv.DurationGt != nil && fv.DurationLt != nil && durationGt+1 >= durationLt:
+		p.fail("field %v has no duration both greater than validator.duration_gt and less than validator.duration_lt", name)
+	}
+}
+
+// parseTimestamp parses an optional timestamp constraint.
+func parseTimestamp(value *string) (time.Time, error) {
+	if value == nil {
+		return time.Time{}, nil
+	}
+	return time.Parse(time.RFC3339Nano, *value)
+}
+
+// parseDuration parses an optional duration constraint.
+func parseDuration(value *string) (time.Duration, error) {
+	if value == nil {
+		return 0, nil
+	}
+	return time.ParseDuration(*value)
+}
+
+// generateTimeValidator checks the Timestamp or Duration pointed to by pointer, which is either a message or a
+// time.Time or time.Duration if gogo's stdtime or stdduration is used.
+func (p *plugin) generateTimeValidator(field *descriptor.FieldDescriptorProto, std bool, pointer string, fieldName string, fv *validator.FieldValidator) {
+	if fv == nil {
+		return
+	}
+	var variableName string
+	switch {
+	case std:
+		variableName = pointee(pointer)
+	case field.GetTypeName() == timestampType:
+		variableName = p.validatorPkg.Use() + ".AsTime(" + pointer + ")"
+	case field.GetTypeName() == durationType:
+		variableName = p.validatorPkg.Use() + ".AsDuration(" + pointer + ")"
+	}
+	switch field.GetTypeName() {
+	case timestampType:
+		p.generateTimestampValidator(variableName, fieldName, fv)
+	case durationType:
+		p.generateDurationValidator(variableName, fieldName, fv)
+	}
+}
+
+func (p *plugin) generateTimestampValidator(variableName string, fieldName string, fv *validator.FieldValidator) {
+	if fv.GetTimestampLtNow() {
+		p.P(`if !`, variableName, `.Before(`, p.timePkg.Use(), `.Now()) {`)
+		p.In()
+		p.generateErrorString(variableName, fieldName, "timestamp_lt_now", true, "be in the past", fv)
+		p.Out()
+		p.P(`}`)
+	}
+	if fv.GetTimestampGtNow() {
+		p.P(`if !`, variableName, `.After(`, p.timePkg.Use(), `.Now()) {`)
+		p.In()
+		p.generateErrorString(variableName, fieldName, "timestamp_gt_now", true, "be in the future", fv)
+		p.Out()
+		p.P(`}`)
+	}
+	if d, err := parseDuration(fv.TimestampWithin); fv.TimestampWithin != nil && err == nil {
+		p.P(fmt.Sprint(`if !`, p.validatorPkg.Use(), `.TimeWithin(`, variableName, `, `, p.timePkg.Use(), `.Duration(`, int64(d), `)) {`))
+		p.In()
+		errorStr := fmt.Sprintf(`be within '%v' of now`, fv.GetTimestampWithin())
+		p.generateErrorString(variableName, fieldName, "timestamp_within", fv.GetTimestampWithin(), errorStr, fv)
+		p.Out()
+		p.P(`}`)
+	}
+	if t, err := parseTimestamp(fv.TimestampGt); fv.TimestampGt != nil && err == nil {
+		p.P(fmt.Sprint(`if !`, variableName, `.After(`, p.timePkg.Use(), `.Unix(`, t.Unix(), `, `, t.Nanosecond(), `)) {`))
+		p.In()
+		errorStr := fmt.Sprintf(`be after '%v'`, fv.GetTimestampGt())
+		p.generateErrorString(variableName, fieldName, "timestamp_gt", fv.GetTimestampGt(), errorStr, fv)
+		p.Out()
+		p.P(`}`)
+	}
+	if t, err := parseTimestamp(fv.TimestampLt); fv.TimestampLt != nil && err == nil {
+		p.P(fmt.Sprint(`if !`, variableName, `.Before(`, p.timePkg.Use(), `.Unix(`, t.Unix(), `, `, t.Nanosecond(), `)) {`))
+		p.In()
+		errorStr := fmt.Sprintf(`be before '%v'`, fv.GetTimestampLt())
+		p.generateErrorString(variableName, fieldName, "timestamp_lt", fv.GetTimestampLt(), errorStr, fv)
+		p.Out()
+		p.P(`}`)
+	}
+}
+
+func (p *plugin) generateDurationValidator(variableName string, fieldName string, fv *validator.FieldValidator) {
+	if d, err := parseDuration(fv.DurationGt); fv.DurationGt != nil && err == nil {
+		p.P(fmt.Sprint(`if !(`, variableName, ` > `, p.timePkg.Use(), `.Duration(`, int64(d), `)) {`))
+		p.In()
+		errorStr := fmt.Sprintf(`be greater than '%v'`, fv.GetDurationGt())
+		p.generateErrorString(variableName, fieldName, "duration_gt", fv.GetDurationGt(), errorStr, fv)
+		p.Out()
+		p.P(`}`)
+	}
+	if d, err := parseDuration(fv.DurationLt); fv.DurationLt != nil && err == nil {
+		p.P(fmt.Sprint(`if !(`, variableName, ` < `, p.timePkg.Use(), `.Duration(`, int64(d), `)) {`))
+		p.In()
+		errorStr := fmt.Sprintf(`be less than '%v'`, fv.GetDurationLt())
+		p.generateErrorString(variableName, fieldName, "duration_lt", fv.GetDurationLt(), errorStr, fv)
+		p.Out()
+		p.P(`}`)
+	}
+}
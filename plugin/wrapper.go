@@ -0,0 +1,66 @@
+// Copyright 2016 Michal Witkowski. All Rights Reserved.
+// See LICENSE for licensing terms.
+
+package plugin
+
+import (
+	"strings"
+
+	descriptor "github.com/gogo/protobuf/protoc-gen-gogo/descriptor"
+	"github.com/mwitkow/go-proto-validators"
+)
+
+// wrapperTypes are the scalar types of the value field of the google.protobuf wrapper messages.
+var wrapperTypes = map[string]descriptor.FieldDescriptorProto_Type{
+	".google.protobuf.DoubleValue": descriptor.FieldDescriptorProto_TYPE_DOUBLE,
+	".google.protobuf.FloatValue":  descriptor.FieldDescriptorProto_TYPE_FLOAT,
+	".google.protobuf.Int64Value":  descriptor.FieldDescriptorProto_TYPE_INT64,
+	".google.protobuf.UInt64Value": descriptor.FieldDescriptorProto_TYPE_UINT64,
+	".google.protobuf.Int32Value":  descriptor.FieldDescriptorProto_TYPE_INT32,
+	".google.protobuf.UInt32Value": descriptor.FieldDescriptorProto_TYPE_UINT32,
+	".google.protobuf.BoolValue":   descriptor.FieldDescriptorProto_TYPE_BOOL,
+	".google.protobuf.StringValue": descriptor.FieldDescriptorProto_TYPE_STRING,
+	".google.protobuf.BytesValue":  descriptor.FieldDescriptorProto_TYPE_BYTES,
+}
+
+// wrappedField returns the value field of a wrapper message field, or nil if the field isn't a wrapper.
+func wrappedField(field *descriptor.FieldDescriptorProto) *descriptor.FieldDescriptorProto {
+	if field.GetType() != descriptor.FieldDescriptorProto_TYPE_MESSAGE {
+		return nil
+	}
+	wrappedType, ok := wrapperTypes[field.GetTypeName()]
+	if !ok {
+		return nil
+	}
+	return &descriptor.FieldDescriptorProto{
+		Name:  field.Name,
+		Label: field.Label,
+		Type:  &wrappedType,
+	}
+}
+
+// generateWrapperValidator applies the scalar constraints of a wrapper message field to the value of the wrapper
+// pointed to by pointer.
+func (p *plugin) generateWrapperValidator(field *descriptor.FieldDescriptorProto, pointer string, ccTypeName string, fieldName string, fv *validator.FieldValidator) {
+	wrapped := wrappedField(field)
+	if wrapped == nil || fv == nil {
+		return
+	}
+	p.generateScalarValidator(wrapped, structField(pointer, "Value"), ccTypeName, fieldName, fv)
+}
+
+// pointee returns the expression of the value pointed to by pointer, which can be used to access its fields and methods.
+func pointee(pointer string) string {
+	if strings.HasPrefix(pointer, "&(") && strings.HasSuffix(pointer, ")") {
+		return pointer[len("&"):]
+	}
+	return "(*" + pointer + ")"
+}
+
+// structField returns the expression of a field of the struct pointed to by pointer.
+func structField(pointer string, name string) string {
+	if strings.HasPrefix(pointer, "&(") {
+		return pointee(pointer) + "." + name
+	}
+	return pointer + "." + name
+}
@@ -4,14 +4,20 @@
 package main
 
 import (
+	"flag"
+	"fmt"
 	"io/ioutil"
 	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
 
 	"github.com/gogo/protobuf/proto"
 	"github.com/gogo/protobuf/protoc-gen-gogo/generator"
 	validator_plugin "github.com/mwitkow/go-proto-validators/plugin"
+	"google.golang.org/protobuf/compiler/protogen"
+	protov2 "google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/pluginpb"
 )
 
 func main() {
@@ -44,19 +50,28 @@ func main() {
 		}
 	}
 
+	if useGogoImport {
+		generateGogo(gen)
+	} else {
+		generateProtogen(data)
+	}
+}
+
+// generateGogo generates validators for the Go types generated by protoc-gen-gogo.
+func generateGogo(gen *generator.Generator) {
 	gen.CommandLineParameters(gen.Request.GetParameter())
 
 	gen.WrapTypes()
 	gen.SetPackageNames()
 	gen.BuildTypeNameMap()
-	gen.GeneratePlugin(validator_plugin.NewPlugin(useGogoImport))
+	gen.GeneratePlugin(validator_plugin.NewPlugin())
 
 	for i := 0; i < len(gen.Response.File); i++ {
 		gen.Response.File[i].Name = proto.String(strings.Replace(*gen.Response.File[i].Name, ".pb.go", ".validator.pb.go", -1))
 	}
 
 	// Send back the results.
-	data, err = proto.Marshal(gen.Response)
+	data, err := proto.Marshal(gen.Response)
 	if err != nil {
 		gen.Error(err, "failed to marshal output proto")
 	}
@@ -65,3 +80,42 @@ func main() {
 		gen.Error(err, "failed to write output proto")
 	}
 }
+
+// generateProtogen generates validators for the Go types generated by protoc-gen-go, supporting its
+// paths=source_relative, module= and M parameters.
+func generateProtogen(data []byte) {
+	var flags flag.FlagSet
+	flags.Bool("gogoimport", false, "generate validators for the Go types generated by protoc-gen-gogo")
+	warningsAsErrors := flags.Bool("warnings_as_errors", false, "fail on problems with the validator annotations that are otherwise only reported as warnings")
+	lazyRegex := flags.Bool("lazy_regex", false, "compile the regexes of the validators when first used")
+
+	req := &pluginpb.CodeGeneratorRequest{}
+	if err := protov2.Unmarshal(data, req); err != nil {
+		fail(err)
+	}
+	gen, err := protogen.Options{ParamFunc: flags.Set}.New(req)
+	if err != nil {
+		fail(err)
+	}
+	gen.SupportedFeatures = uint64(pluginpb.CodeGeneratorResponse_FEATURE_PROTO3_OPTIONAL)
+	opts := validator_plugin.Options{WarningsAsErrors: *warningsAsErrors, LazyRegex: *lazyRegex}
+	for _, file := range gen.Files {
+		if file.Generate {
+			opts.GenerateFile(gen, file)
+		}
+	}
+
+	// Send back the results.
+	data, err = protov2.Marshal(gen.Response())
+	if err != nil {
+		fail(err)
+	}
+	if _, err := os.Stdout.Write(data); err != nil {
+		fail(err)
+	}
+}
+
+func fail(err error) {
+	fmt.Fprintf(os.Stderr, "%s: %v\n", filepath.Base(os.Args[0]), err)
+	os.Exit(1)
+}
@@ -0,0 +1,46 @@
+// Copyright 2016 Michal Witkowski. All Rights Reserved.
+// See LICENSE for licensing terms.
+
+package validator
+
+import (
+	"errors"
+	"strings"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// BadRequestStatus converts an error returned by a Validate or ValidateAll method into an InvalidArgument status.
+// The status carries a google.rpc.BadRequest detail with a field violation for every Violation in err, identifying
+// the offending field by its dotted path of .proto field names.
+// It returns nil if err is nil.
+func BadRequestStatus(err error) *status.Status {
+	if err == nil {
+		return nil
+	}
+	st := status.New(codes.InvalidArgument, err.Error())
+	var errs Errors
+	if !errors.As(err, &errs) {
+		errs = Errors{err}
+	}
+	badRequest := &errdetails.BadRequest{}
+	for _, err := range errs {
+		var violation *Violation
+		if !errors.As(err, &violation) {
+			continue
+		}
+		badRequest.FieldViolations = append(badRequest.FieldViolations, &errdetails.BadRequest_FieldViolation{
+			Field:       strings.Join(violation.ProtoFieldPath, "."),
+			Description: violation.Err.Error(),
+		})
+	}
+	if len(badRequest.FieldViolations) == 0 {
+		return st
+	}
+	if withDetails, err := st.WithDetails(badRequest); err == nil {
+		return withDetails
+	}
+	return st
+}
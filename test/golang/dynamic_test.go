@@ -0,0 +1,329 @@
+// Copyright 2016 Michal Witkowski. All Rights Reserved.
+// See LICENSE for licensing terms.
+
+package validatortest
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"testing"
+	"time"
+
+	gogoproto "github.com/gogo/protobuf/proto"
+	gogodescriptor "github.com/gogo/protobuf/protoc-gen-gogo/descriptor"
+	"github.com/golang/protobuf/proto"
+	validator "github.com/mwitkow/go-proto-validators"
+	"github.com/mwitkow/go-proto-validators/dynamic"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/protoadapt"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+	"google.golang.org/protobuf/types/known/anypb"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+type generatedValidator interface {
+	proto.Message
+	validator.Validator
+	validator.AllValidator
+}
+
+func dynamicTestCases() map[string]generatedValidator {
+	badRegex := buildProto3("toolong", 11, "abba", 99, 0.5, 0.5, 0.5, 0.5, "x", 4, "1234567890", stableBytes)
+	badInt := buildProto3("-%ab", 5, "abba", 99, 0.5, 0.5, 0.5, 0.5, "x", 4, "1234567890", stableBytes)
+	badDoubleStrict := buildProto3("-%ab", 11, "abba", 99, 0.25, 0.5, 0.5, 0.5, "x", 4, "1234567890", stableBytes)
+	badFloatStrict := buildProto3("-%ab", 11, "abba", 99, 0.5, 0.3, 0.5, 0.5, "x", 4, "1234567890", stableBytes)
+	badFloat := buildProto3("-%ab", 11, "abba", 99, 0.5, 0.5, 0.5, 0.2, "x", 4, "1234567890", stableBytes)
+	badNonEmpty := buildProto3("-%ab", 11, "abba", 99, 0.5, 0.5, 0.5, 0.5, "", 4, "1234567890", stableBytes)
+	badRepeatedCount := buildProto3("-%ab", 11, "abba", 99, 0.5, 0.5, 0.5, 0.5, "x", 1, "1234567890", stableBytes)
+	badLength := buildProto3("-%ab", 11, "abba", 99, 0.5, 0.5, 0.5, 0.5, "x", 4, "abc456", []byte("anc"))
+	missingEmbedded := buildProto3("-%ab", 11, "abba", 99, 0.5, 0.5, 0.5, 0.5, "x", 4, "1234567890", stableBytes)
+	missingEmbedded.SomeEmbeddedExists = nil
+	badNested := buildProto3("-%ab", 11, "abba", 99, 0.5, 0.5, 0.5, 0.5, "x", 4, "1234567890", stableBytes)
+	badNested.SomeEmbeddedExists = &ValidatorMessage3_Embedded{Identifier: "abba", SomeValue: 101}
+	customError := buildProto3("-%ab", 11, "abba", 99, 0.5, 0.5, 0.5, 0.5, "x", 4, "1234567890", stableBytes)
+	customError.CustomErrorInt = 30
+	manyErrors := buildProto3("toolong", 5, "abba", 99, 0.5, 0.5, 0.5, 0.5, "", 1, "1234567890", stableBytes)
+	badProto2 := buildProto2("toolong", 5, "abba", 99, 0.5, 0.5, 0.5, 0.5, "x", 4, "1234567890", stableBytes)
+	badMapCount := buildMapProto3()
+	badMapCount.SomeConstrainedMap = nil
+	badMapKey := buildMapProto3()
+	badMapKey.SomeConstrainedMap = map[string]string{"ABC": "1234"}
+	badMapValue := buildMapProto3()
+	badMapValue.SomeConstrainedMap = map[string]string{"abc": "12345"}
+	badMapNested := buildMapProto3()
+	badMapNested.SomeExtMap = map[string]*ValueType{"b": {Something: "ABC"}}
+	badMapExists := buildMapProto3()
+	badMapExists.SomeExistsMap = map[int64]*ValueType{-1: {Something: "abc"}}
+	badOneOf := &OneOfMessage3{
+		SomeInt:   30,
+		Type:      &OneOfMessage3_OneMsg{OneMsg: &ExternalMsg{Identifier: "999", SomeValue: 99}},
+		Something: &OneOfMessage3_ThreeInt{ThreeInt: 19},
+	}
+	missingOneOf := &OneOfMessage3{
+		SomeInt: 30,
+		Type:    &OneOfMessage3_OneInt{OneInt: 21},
+	}
+	missingRequiredOpt := buildProto2("-%ab", 11, "abba", 99, 0.5, 0.5, 0.5, 0.5, "x", 4, "1234567890", stableBytes)
+	missingRequiredOpt.RequiredOpt = nil
+	badOptional := buildOptionalProto3()
+	badOptionalInt := uint32(0)
+	badOptional.SomeInt = &badOptionalInt
+	missingOptional := buildOptionalProto3()
+	missingOptional.RequiredString = nil
+	badInterval := buildIntervalProto3()
+	badInterval.StartTime, badInterval.EndTime = badInterval.EndTime, badInterval.StartTime
+	badInterval.MinDuration, badInterval.MaxDuration = badInterval.MaxDuration, badInterval.MinDuration
+	missingIntervalEnd := buildIntervalProto3()
+	missingIntervalEnd.EndTime = nil
+	missingIntervalName := buildIntervalProto3()
+	missingIntervalName.Name = ""
+	missingIntervalName.Tagged = true
+	badCelName := buildCelProto3()
+	badCelName.Name = "name"
+	badCelValues := buildCelProto3()
+	badCelValues.Values = []int64{0, 1, 2, 3}
+	badCelCounts := buildCelProto3()
+	badCelCounts.Counts = map[string]int64{"a": 10}
+	badCelInner := buildCelProto3()
+	badCelInner.SomeInner.Value = ""
+	badCelMessage := buildCelProto3()
+	badCelMessage.Lower = 3
+	badTimestamp := buildTimeProto3()
+	badTimestamp.PastTime = timestamppb.New(time.Now().Add(time.Hour))
+	badTimestamp.BoundedTime = timestamppb.New(time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC))
+	badDuration := buildTimeProto3()
+	badDuration.Timeout = durationpb.New(time.Hour)
+	badDuration.Delays = []*durationpb.Duration{durationpb.New(time.Second)}
+	badDuration.Timeouts["b"] = durationpb.New(0)
+	badWrapper := buildWrapperProto3()
+	badWrapper.Count.Value = 0
+	badWrapper.Ratio.Value = -1
+	badWrapper.Ids[0].Value = 0
+	badWrapper.Labels["b"] = &wrapperspb.StringValue{}
+	missingWrapper := buildWrapperProto3()
+	missingWrapper.Name = nil
+	badAny := buildAnyProto3()
+	badAny.Details = anyProto(&emptypb.Empty{})
+	badAny.Payload = anyProto(&AnyPayload3{Name: "ABC"})
+	badAny.Payloads = append(badAny.Payloads, &anypb.Any{TypeUrl: "type.googleapis.com/validatortest.Unknown"})
+	badAny.Attributes["b"] = anyProto(&AnyPayload3{})
+	badEnum := buildEnumProto3()
+	badEnum.Defined = Color(7)
+	badEnum.Specified = Color_COLOR_UNSPECIFIED
+	badEnum.Colors = []Color{0, 4}
+	badEnum.Palette["b"] = Color_GREEN
+	badIntBounds := &IntMessage3{Percent: 101, Priority: 4, Port: 22, Version: 3, Codes: []int32{99}, Limits: map[string]int64{"a": 0}}
+	badUint := buildIntProto3()
+	badUint.Serial = 1
+	badUint.Capacity = math.MaxUint64
+	badUint.Checksum = 0
+	badUint.Offset = -11
+	notFiniteFloat := buildFloatProto3()
+	notFiniteFloat.Ratio = math.NaN()
+	notFiniteFloat.Weight = float32(math.Inf(1))
+	notFiniteFloat.Measured = math.NaN()
+	notFiniteFloat.Scores["b"] = math.Inf(-1)
+	notFiniteFloat.Offset.Value = math.NaN()
+	notFiniteFloat.Peak = float32(math.Inf(1))
+	badFormat := &FormatMessage3{Address: "x", Gateway: "2001:db8::1", Subnet: "192.0.2.0/33", Server: ":443"}
+	badFormat.Servers = map[string]string{"b c": "d"}
+	badIdentifier := &IdentifierMessage3{Id: "x", RequestId: "f81d4fae-7dec-11d0-a765-00a0c91e6bf6", DevEui: "0011", DevAddr: []byte{1}}
+	badIdentifier.Sessions = map[string]string{"x": "y"}
+	badContact := &ContactMessage3{Email: "jane", Website: "/", Callback: "http://user@example.com", Source: "x", Link: "ftp://a"}
+	schemelessContact := buildContactProto3()
+	schemelessContact.Callback = "//evil.example/x"
+	schemelessContact.Link = "//example.com/docs"
+	badEncoding := &EncodingMessage3{Key: "x", Token: "x=", Config: "{", Payload: []byte{0xff}}
+	return map[string]generatedValidator{
+		"GoodProto3":          buildProto3("-%ab", 11, "abba", 99, 0.5, 0.5, 0.5, 0.5, "x", 4, "1234567890", stableBytes),
+		"GoodProto2":          buildProto2("-%ab", 11, "abba", 99, 0.5, 0.5, 0.5, 0.5, "x", 4, "1234567890", stableBytes),
+		"GoodMap":             buildMapProto3(),
+		"BadRegex":            badRegex,
+		"BadInt":              badInt,
+		"BadDoubleStrict":     badDoubleStrict,
+		"BadFloatStrict":      badFloatStrict,
+		"BadFloat":            badFloat,
+		"BadNonEmpty":         badNonEmpty,
+		"BadRepeatedCount":    badRepeatedCount,
+		"BadLength":           badLength,
+		"MissingEmbedded":     missingEmbedded,
+		"BadNested":           badNested,
+		"CustomError":         customError,
+		"ManyErrors":          manyErrors,
+		"BadProto2":           badProto2,
+		"BadMapCount":         badMapCount,
+		"BadMapKey":           badMapKey,
+		"BadMapValue":         badMapValue,
+		"BadMapNested":        badMapNested,
+		"BadMapExists":        badMapExists,
+		"BadOneOf":            badOneOf,
+		"MissingOneOf":        missingOneOf,
+		"MissingRequiredOpt":  missingRequiredOpt,
+		"GoodOptional":        buildOptionalProto3(),
+		"BadOptional":         badOptional,
+		"MissingOptional":     missingOptional,
+		"GoodInterval":        buildIntervalProto3(),
+		"BadInterval":         badInterval,
+		"MissingIntervalEnd":  missingIntervalEnd,
+		"MissingIntervalName": missingIntervalName,
+		"GoodCel":             buildCelProto3(),
+		"BadCelName":          badCelName,
+		"BadCelValues":        badCelValues,
+		"BadCelCounts":        badCelCounts,
+		"BadCelInner":         badCelInner,
+		"BadCelMessage":       badCelMessage,
+		"GoodTime":            buildTimeProto3(),
+		"BadTimestamp":        badTimestamp,
+		"BadDuration":         badDuration,
+		"GoodWrapper":         buildWrapperProto3(),
+		"BadWrapper":          badWrapper,
+		"MissingWrapper":      missingWrapper,
+		"GoodAny":             buildAnyProto3(),
+		"BadAny":              badAny,
+		"GoodEnum":            buildEnumProto3(),
+		"BadEnum":             badEnum,
+		"GoodInt":             buildIntProto3(),
+		"BadIntBounds":        badIntBounds,
+		"BadUint":             badUint,
+		"GoodFloat":           buildFloatProto3(),
+		"NotFiniteFloat":      notFiniteFloat,
+		"GoodFormat":          buildFormatProto3(),
+		"BadFormat":           badFormat,
+		"GoodIdentifier":      buildIdentifierProto3(),
+		"BadIdentifier":       badIdentifier,
+		"GoodContact":         buildContactProto3(),
+		"BadContact":          badContact,
+		"SchemelessContact":   schemelessContact,
+		"BadRange":            &RangeMessage3{LowerBound: 200, UpperBound: 100},
+		"GoodEncoding":        buildEncodingProto3(),
+		"BadEncoding":         badEncoding,
+		"UnregisteredPayload": &UnregisteredPayloadMessage3{},
+		"GoodText":            buildTextProto3(),
+		"BadText":             &TextMessage3{Name: "日本語日本語", Code: "ab", Tags: []string{"abcd"}},
+	}
+}
+
+func assertSameViolations(t *testing.T, expected error, actual error) {
+	if expected == nil {
+		assert.NoError(t, actual)
+		return
+	}
+	if !assert.Error(t, actual) {
+		return
+	}
+	assert.Equal(t, expected.Error(), actual.Error())
+	var expectedViolation, actualViolation *validator.Violation
+	if errors.As(expected, &expectedViolation) && assert.True(t, errors.As(actual, &actualViolation)) {
+		assert.Equal(t, expectedViolation.FieldPath, actualViolation.FieldPath)
+		assert.Equal(t, expectedViolation.ProtoFieldPath, actualViolation.ProtoFieldPath)
+		assert.Equal(t, expectedViolation.Constraint, actualViolation.Constraint)
+		assert.Equal(t, expectedViolation.ConstraintValue, actualViolation.ConstraintValue)
+	}
+}
+
+func TestDynamic_SameErrorsAsGenerated(t *testing.T) {
+	for name, msg := range dynamicTestCases() {
+		t.Run(name, func(t *testing.T) {
+			assertSameViolations(t, msg.Validate(), dynamic.Validate(protoadapt.MessageV2Of(msg)))
+			assertSameViolations(t, msg.ValidateAll(), dynamic.ValidateAll(protoadapt.MessageV2Of(msg)))
+		})
+	}
+}
+
+func TestDynamic_DynamicMessage(t *testing.T) {
+	for name, msg := range dynamicTestCases() {
+		t.Run(name, func(t *testing.T) {
+			b, err := proto.Marshal(msg)
+			if err != nil {
+				t.Fatalf("failed to marshal: %v", err)
+			}
+			dynamicMsg := dynamicpb.NewMessage(protoadapt.MessageV2Of(msg).ProtoReflect().Descriptor())
+			if err := proto.Unmarshal(b, protoadapt.MessageV1Of(dynamicMsg)); err != nil {
+				t.Fatalf("failed to unmarshal: %v", err)
+			}
+			assertSameViolations(t, msg.Validate(), dynamic.Validate(dynamicMsg))
+		})
+	}
+}
+
+func TestDynamic_LoadedDescriptors(t *testing.T) {
+	// Descriptors loaded at runtime are new on every load, and are validated with a Validator owned by the caller.
+	loaded := &dynamic.Validator{}
+	for name, msg := range dynamicTestCases() {
+		t.Run(name, func(t *testing.T) {
+			md := protoadapt.MessageV2Of(msg).ProtoReflect().Descriptor()
+			// The validator options are only registered with gogo/protobuf, and are kept as unknown fields.
+			options := protodesc.FileOptions{AllowUnresolvable: true}
+			file, err := options.New(protodesc.ToFileDescriptorProto(md.ParentFile()), protoregistry.GlobalFiles)
+			if err != nil {
+				t.Fatalf("failed to load descriptor: %v", err)
+			}
+			b, err := proto.Marshal(msg)
+			if err != nil {
+				t.Fatalf("failed to marshal: %v", err)
+			}
+			dynamicMsg := dynamicpb.NewMessage(file.Messages().ByName(md.Name()))
+			if err := proto.Unmarshal(b, protoadapt.MessageV1Of(dynamicMsg)); err != nil {
+				t.Fatalf("failed to unmarshal: %v", err)
+			}
+			for i := 0; i < 2; i++ {
+				assertSameViolations(t, msg.ValidateAll(), loaded.ValidateAll(dynamicMsg))
+				assertSameViolations(t, msg.Validate(), dynamic.Validate(dynamicMsg))
+			}
+		})
+	}
+}
+
+func TestDynamic_UintBoundOnSignedField(t *testing.T) {
+	// The plugin rejects uint constraints on signed fields, but descriptors built at runtime can still have them.
+	gogoOptions := &gogodescriptor.FieldOptions{}
+	if err := gogoproto.SetExtension(gogoOptions, validator.E_Field, &validator.FieldValidator{UintGt: gogoproto.Uint64(10)}); err != nil {
+		t.Fatalf("failed to set the field options: %v", err)
+	}
+	b, err := gogoproto.Marshal(gogoOptions)
+	if err != nil {
+		t.Fatalf("failed to marshal the field options: %v", err)
+	}
+	options := &descriptorpb.FieldOptions{}
+	if err := proto.Unmarshal(b, options); err != nil {
+		t.Fatalf("failed to unmarshal the field options: %v", err)
+	}
+	file, err := protodesc.NewFile(&descriptorpb.FileDescriptorProto{
+		Name:    proto.String("uint_bound.proto"),
+		Package: proto.String("validatortest.uintbound"),
+		Syntax:  proto.String("proto3"),
+		MessageType: []*descriptorpb.DescriptorProto{{
+			Name: proto.String("SignedMessage"),
+			Field: []*descriptorpb.FieldDescriptorProto{{
+				Name:     proto.String("value"),
+				Number:   proto.Int32(1),
+				Label:    descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+				Type:     descriptorpb.FieldDescriptorProto_TYPE_INT64.Enum(),
+				JsonName: proto.String("value"),
+				Options:  options,
+			}},
+		}},
+	}, nil)
+	if err != nil {
+		t.Fatalf("failed to load descriptor: %v", err)
+	}
+	md := file.Messages().Get(0)
+	for value, valid := range map[int64]bool{-1: false, 10: false, 11: true} {
+		msg := dynamicpb.NewMessage(md)
+		msg.Set(md.Fields().Get(0), protoreflect.ValueOfInt64(value))
+		err := (&dynamic.Validator{}).Validate(msg)
+		if valid {
+			assert.NoError(t, err, "value %d", value)
+		} else {
+			assert.EqualError(t, err, fmt.Sprintf("invalid field Value: value '%d' must be greater than '10'", value))
+		}
+	}
+}
@@ -0,0 +1,29 @@
+// Copyright 2016 Michal Witkowski. All Rights Reserved.
+// See LICENSE for licensing terms.
+
+package validatorlazytest
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLazyRegex(t *testing.T) {
+	example := &LazyRegexMessage3{
+		Identifier: "abba",
+		Labels:     map[string]string{"env": "prod"},
+		Choice:     &LazyRegexMessage3_Name{Name: "abc"},
+	}
+	assert.NoError(t, example.Validate())
+	example.Identifier = "toolong"
+	assert.EqualError(t, example.Validate(), `invalid field Identifier: value 'toolong' must be a string conforming to regex "^[a-z]{2,5}$"`)
+	example.Identifier = "abba"
+	example.Labels["Env"] = "prod"
+	assert.EqualError(t, example.Validate(), `invalid field Labels[Env]: value 'Env' must be a string conforming to regex "^[a-z]+$"`)
+	delete(example.Labels, "Env")
+	example.Choice = &LazyRegexMessage3_Name{Name: "ABC"}
+	assert.EqualError(t, example.Validate(), `invalid field Name: value 'ABC' must be a string conforming to regex "^[a-z]+$"`)
+	example.Choice = &LazyRegexMessage3_Code{Code: "abc"}
+	assert.EqualError(t, example.Validate(), `invalid field Code: value 'abc' must be a string conforming to regex "^[A-Z]{3}$"`)
+}